@@ -1,7 +1,10 @@
 package compiler
 
 import (
+	"fmt"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/drone/drone-runtime/engine"
 	"github.com/drone/drone-runtime/version"
@@ -30,12 +33,25 @@ type (
 		Token    string
 	}
 
+	// RewriteRule represents a rule used to rewrite the fully
+	// qualified image name, for example, to pull images from a
+	// registry mirror. A trailing wildcard in the source matches
+	// the remainder of the image name, which is substituted for
+	// the trailing wildcard in the target.
+	RewriteRule struct {
+		From string
+		To   string
+	}
+
 	// Secret represents a repository secret that should
 	// be passed to the container at runtime.
 	Secret struct {
-		Name  string
-		Value string
-		Match []string
+		Name   string
+		Value  string
+		Match  []string
+		Event  yaml.Constraint
+		Branch yaml.Constraint
+		Fork   bool
 	}
 
 	// Metadata represents pipeline metadata required to
@@ -47,6 +63,7 @@ type (
 		Environment string
 		Event       string
 		Branch      string
+		Fork        bool
 		Matrix      map[string]string
 	}
 )
@@ -55,7 +72,13 @@ type (
 type Compiler struct {
 	metadata   Metadata
 	noclone    bool
+	cloneImage string
 	transforms []Transform
+	rewrites   []RewriteRule
+	registries []Registry
+	lock       ImageLock
+	lockStrict bool
+	timeout    time.Duration
 }
 
 // New returns a new compiler
@@ -79,11 +102,10 @@ func (c *Compiler) Compile(conf *config.Config) (*engine.Config, error) {
 	spec := new(engine.Config)
 	spec.Version = version.VersionMajor
 
+	platform := LookupPlatform(conf.Platform)
+
 	if _, ok := conf.Networks["default"]; !ok {
-		dst := &engine.Network{Driver: "bridge", Name: "default"}
-		if conf.Platform.Name == "windows/amd64" {
-			dst.Driver = "nat"
-		}
+		dst := &engine.Network{Driver: platform.NetworkDriver, Name: "default"}
 		spec.Networks = append(spec.Networks, dst)
 	}
 
@@ -93,31 +115,29 @@ func (c *Compiler) Compile(conf *config.Config) (*engine.Config, error) {
 	}
 
 	for name, src := range conf.Networks {
-		dst := &engine.Network{Driver: src.Driver, Name: name}
+		dst := &engine.Network{
+			Driver:     src.Driver,
+			DriverOpts: src.DriverOpts,
+			Name:       name,
+		}
 		spec.Networks = append(spec.Networks, dst)
 	}
 
 	for name, src := range conf.Volumes {
-		dst := &engine.Volume{Driver: src.Driver, Name: name}
+		dst := &engine.Volume{
+			Driver:     src.Driver,
+			DriverOpts: src.DriverOpts,
+			Name:       name,
+		}
 		spec.Volumes = append(spec.Volumes, dst)
 	}
 
 	if c.noclone == false && conf.Clone.Disable == false {
-		image := "drone/git"
-		switch conf.Platform.Name {
-		case "linux/arm":
-			image = "drone/git:linux-arm"
-		case "linux/arm64":
-			image = "drone/git:linux-arm64"
-		case "windows/amd64":
-			image = "drone/git:windows-1803"
-		}
-
 		dst := &engine.Step{}
-		src := &yaml.Container{Name: "clone", Image: image}
+		src := c.cloneContainer(conf)
 		copyContainer(dst, src)
-		for _, t := range c.transforms {
-			t(dst, src, conf)
+		if err := c.transform(dst, src, conf); err != nil {
+			return nil, err
 		}
 		stage := new(engine.Stage)
 		spec.Stages = append(spec.Stages, stage)
@@ -125,22 +145,42 @@ func (c *Compiler) Compile(conf *config.Config) (*engine.Config, error) {
 	}
 
 	if len(conf.Services) != 0 {
+		var services []*yaml.Container
 		stage := new(engine.Stage)
 		for name, src := range conf.Services {
 			src.Name = name
 			dst := new(engine.Step)
 			copyService(dst, src)
-			for _, t := range c.transforms {
-				t(dst, src, conf)
+			if err := c.transform(dst, src, conf); err != nil {
+				return nil, err
 			}
 			if calcSkip(src, c.metadata) {
 				continue
 			}
 			stage.Steps = append(stage.Steps, dst)
+			services = append(services, src)
 		}
 		if len(stage.Steps) != 0 {
 			spec.Stages = append(spec.Stages, stage)
 		}
+
+		// the service readiness step is added to its own stage,
+		// which blocks the pipeline stages until the services
+		// accept connections.
+		src, err := waitContainer(conf, services)
+		if err != nil {
+			return nil, err
+		}
+		if src != nil {
+			dst := new(engine.Step)
+			copyContainer(dst, src)
+			if err := c.transform(dst, src, conf); err != nil {
+				return nil, err
+			}
+			stage := new(engine.Stage)
+			stage.Steps = append(stage.Steps, dst)
+			spec.Stages = append(spec.Stages, stage)
+		}
 	}
 
 	for _, group := range conf.Pipeline {
@@ -149,8 +189,8 @@ func (c *Compiler) Compile(conf *config.Config) (*engine.Config, error) {
 			src.Name = name
 			dst := new(engine.Step)
 			copyContainer(dst, src)
-			for _, t := range c.transforms {
-				t(dst, src, conf)
+			if err := c.transform(dst, src, conf); err != nil {
+				return nil, err
 			}
 			if calcSkip(src, c.metadata) {
 				continue
@@ -166,6 +206,86 @@ func (c *Compiler) Compile(conf *config.Config) (*engine.Config, error) {
 	return spec, nil
 }
 
+// helper function returns the clone container. The clone settings
+// are passed to the clone plugin as plugin parameters.
+func (c *Compiler) cloneContainer(conf *config.Config) *yaml.Container {
+	image := LookupPlatform(conf.Platform).CloneImage
+	if c.cloneImage != "" {
+		image = c.cloneImage
+	}
+	if conf.Clone.Image != "" {
+		image = conf.Clone.Image
+	}
+
+	vargs := map[string]interface{}{}
+	if conf.Clone.Depth != 0 {
+		vargs["depth"] = conf.Clone.Depth
+	}
+	if conf.Clone.Tags {
+		vargs["tags"] = true
+	}
+	if conf.Clone.Recursive {
+		vargs["recursive"] = true
+	}
+	if conf.Clone.SkipVerify {
+		vargs["skip_verify"] = true
+	}
+	if conf.Clone.LFS {
+		vargs["lfs"] = true
+	}
+	return &yaml.Container{
+		Name:  "clone",
+		Image: image,
+		Vargs: vargs,
+	}
+}
+
+// helper function applies the transforms to the container. The step
+// timeout is resolved before the transforms are applied. Image
+// rewrite rules and the image lock are applied after the transforms,
+// so that image matching uses the original image name, and before
+// registry credentials are matched against the rewritten image name.
+func (c *Compiler) transform(dst *engine.Step, src *yaml.Container, conf *config.Config) error {
+	if timeout := c.calcTimeout(dst, src, conf); timeout != 0 {
+		// the container is copied to avoid mutating the
+		// configuration when the step timeout is defaulted.
+		copy := *src
+		copy.Timeout = yaml.Duration(timeout)
+		src = &copy
+		dst.Environment["DRONE_STEP_TIMEOUT"] = strconv.FormatInt(int64(timeout/time.Second), 10)
+	}
+	for _, t := range c.transforms {
+		t(dst, src, conf)
+	}
+	dst.Image = rewriteImage(dst.Image, c.rewrites...)
+	if c.lock != nil {
+		image, ok := pinImage(dst.Image, c.lock)
+		if !ok && c.lockStrict {
+			return fmt.Errorf("Cannot find image %s in the lock file", dst.Image)
+		}
+		dst.Image = image
+	}
+	transformRegistry(c.registries...)(dst, src, conf)
+	return nil
+}
+
+// helper function returns the step timeout. The pipeline timeout
+// is used if the step timeout is not set, and the timeout is capped
+// by the maximum timeout. Detached steps do not timeout.
+func (c *Compiler) calcTimeout(dst *engine.Step, src *yaml.Container, conf *config.Config) time.Duration {
+	if dst.Detached {
+		return 0
+	}
+	timeout := time.Duration(src.Timeout)
+	if timeout <= 0 {
+		timeout = time.Duration(conf.Timeout)
+	}
+	if timeout <= 0 || (c.timeout > 0 && timeout > c.timeout) {
+		timeout = c.timeout
+	}
+	return timeout
+}
+
 // helper function copies the service contianer configuration from the
 // yaml container to the engine container representation.
 func copyService(dst *engine.Step, src *yaml.Container) {
@@ -181,6 +301,8 @@ func copyContainer(dst *engine.Step, src *yaml.Container) {
 	dst.Pull = src.Pull
 	dst.Detached = src.Detached
 	dst.Privileged = src.Privileged
+	dst.CapAdd = src.CapAdd
+	dst.CapDrop = src.CapDrop
 	dst.Environment = src.Environment.Map
 	dst.Labels = src.Labels.Map
 	dst.Entrypoint = src.Entrypoint
@@ -192,6 +314,13 @@ func copyContainer(dst *engine.Step, src *yaml.Container) {
 	dst.NetworkMode = src.NetworkMode
 	dst.IpcMode = src.IpcMode
 	dst.Sysctls = src.Sysctls.Map
+	dst.MemLimit = int64(src.MemLimit)
+	dst.MemSwapLimit = int64(src.MemSwapLimit)
+	dst.MemSwappiness = int64(src.MemSwappiness)
+	dst.ShmSize = int64(src.ShmSize)
+	dst.CPUQuota = int64(src.CPUQuota)
+	dst.CPUShares = int64(src.CPUShares)
+	dst.CPUSet = src.CPUSet
 	dst.ErrIgnore = src.ErrIgnore
 	dst.OnSuccess = calcOnSucess(src)
 	dst.OnFailure = calcOnFailure(src)
@@ -220,19 +349,46 @@ func defaultContainerNetwork(dst *engine.Step, src *yaml.Container) {
 // container to the engine container representation.
 func copyContainerVolume(dst *engine.Step, src *yaml.Container) {
 	for _, vol := range src.Volumes {
+		if vol.Type == yaml.VolumeTypeTmpfs {
+			copyContainerTmpfs(dst, vol)
+			continue
+		}
 		volume := &engine.VolumeMapping{}
 		volume.Target = vol.Destination
-		if strings.HasPrefix(vol.Source, `\\.\pipe\`) {
+		volume.ReadOnly = vol.ReadOnly
+		switch {
+		case vol.Type == yaml.VolumeTypeBind:
+			volume.Source = vol.Source
+		case vol.Type == yaml.VolumeTypeVolume:
+			volume.Name = vol.Source
+		case strings.HasPrefix(vol.Source, `\\.\pipe\`):
 			volume.Source = vol.Source
-		} else if strings.HasPrefix(vol.Source, "/") {
+		case strings.HasPrefix(vol.Source, "/"):
 			volume.Source = vol.Source
-		} else {
+		default:
 			volume.Name = vol.Source
 		}
 		dst.Volumes = append(dst.Volumes, volume)
 	}
 }
 
+// helper function copies the tmpfs volume configuration from the
+// yaml container to the engine container representation.
+func copyContainerTmpfs(dst *engine.Step, vol *yaml.Volume) {
+	var opts []string
+	if vol.ReadOnly {
+		opts = append(opts, "ro")
+	}
+	if vol.TmpfsSize != 0 {
+		opts = append(opts, fmt.Sprintf("size=%d", vol.TmpfsSize))
+	}
+	if len(opts) == 0 {
+		dst.Tmpfs = append(dst.Tmpfs, vol.Destination)
+		return
+	}
+	dst.Tmpfs = append(dst.Tmpfs, vol.Destination+":"+strings.Join(opts, ","))
+}
+
 // helper function copies the network configuration from the yaml
 // container to the engine container representation.
 func copyContainerNetwork(dst *engine.Step, src *yaml.Container) {
@@ -1 +1,279 @@
 package compiler
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/drone/drone-yaml-v1/config"
+)
+
+func TestCompileImageRewrite(t *testing.T) {
+	conf, err := config.ParseString(`
+pipeline:
+  - publish:
+      image: plugins/docker
+      repo: octocat/hello-world
+`)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	spec, _ := New(
+		WithClone(false),
+		WithPrivileged("plugins/docker"),
+		WithRegistry(Registry{Hostname: "mirror.corp", Username: "octocat"}),
+		WithImageRewrite(RewriteRule{From: "docker.io/*", To: "mirror.corp/dockerhub/*"}),
+	).Compile(conf)
+
+	step := spec.Stages[0].Steps[0]
+	if got, want := step.Image, "mirror.corp/dockerhub/plugins/docker:latest"; got != want {
+		t.Errorf("Want image rewritten to %s, got %s", want, got)
+	}
+	if !step.Privileged {
+		t.Errorf("Want privileged images matched before rewrite")
+	}
+	if got, want := step.AuthConfig.Username, "octocat"; got != want {
+		t.Errorf("Want registry credentials matched after rewrite")
+	}
+}
+
+func TestCompileCapabilities(t *testing.T) {
+	conf, err := config.ParseString(`
+pipeline:
+  - debug:
+      image: golang
+      cap_add: [ SYS_PTRACE ]
+      cap_drop: [ NET_RAW ]
+      commands: [ dlv test ]
+`)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	spec, _ := New(WithClone(false)).Compile(conf)
+
+	step := spec.Stages[0].Steps[0]
+	if len(step.CapAdd) != 1 || step.CapAdd[0] != "SYS_PTRACE" {
+		t.Errorf("Want cap_add copied to the step, got %v", step.CapAdd)
+	}
+	if len(step.CapDrop) != 1 || step.CapDrop[0] != "NET_RAW" {
+		t.Errorf("Want cap_drop copied to the step, got %v", step.CapDrop)
+	}
+}
+
+func TestCompileVolumes(t *testing.T) {
+	conf, err := config.ParseString(`
+pipeline:
+  - build:
+      image: golang
+      commands: [ go build ]
+      volumes:
+        - /opt/cache:/cache:ro
+        - { type: volume, source: deps, target: /deps, read_only: true }
+        - { type: tmpfs, target: /scratch, tmpfs: { size: 64mb } }
+volumes:
+  deps:
+    driver: local
+`)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	spec, _ := New(WithClone(false)).Compile(conf)
+
+	step := spec.Stages[0].Steps[0]
+	if len(step.Volumes) != 2 {
+		t.Errorf("Want 2 volume mappings, got %d", len(step.Volumes))
+		return
+	}
+	if got := step.Volumes[0]; got.Source != "/opt/cache" || !got.ReadOnly {
+		t.Errorf("Want read-only bind mount /opt/cache, got %v", got)
+	}
+	if got := step.Volumes[1]; got.Name == "" || got.Target != "/deps" || !got.ReadOnly {
+		t.Errorf("Want read-only named volume mounted at /deps, got %v", got)
+	}
+	if len(step.Tmpfs) != 1 || step.Tmpfs[0] != "/scratch:size=67108864" {
+		t.Errorf("Want tmpfs mount /scratch, got %v", step.Tmpfs)
+	}
+}
+
+func TestCompileDriverOpts(t *testing.T) {
+	conf, err := config.ParseString(`
+pipeline:
+  - build:
+      image: golang
+      commands: [ go build ]
+volumes:
+  cache:
+    driver: local
+    driver_opts:
+      type: nfs
+      o: addr=10.0.0.1
+networks:
+  backend:
+    driver: overlay
+    driver_opts:
+      encrypted: "true"
+`)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	spec, _ := New(WithClone(false)).Compile(conf)
+
+	var volumeOpts, networkOpts map[string]string
+	for _, volume := range spec.Volumes {
+		if strings.HasSuffix(volume.Name, "cache") {
+			volumeOpts = volume.DriverOpts
+		}
+	}
+	for _, network := range spec.Networks {
+		if strings.HasSuffix(network.Name, "backend") {
+			networkOpts = network.DriverOpts
+		}
+	}
+	if got, want := volumeOpts["type"], "nfs"; got != want {
+		t.Errorf("Want volume driver_opts copied, got %v", volumeOpts)
+	}
+	if got, want := networkOpts["encrypted"], "true"; got != want {
+		t.Errorf("Want network driver_opts copied, got %v", networkOpts)
+	}
+}
+
+func TestCompileClone(t *testing.T) {
+	conf, err := config.ParseString(`
+clone:
+  depth: 50
+  tags: true
+  recursive: true
+  skip_verify: true
+  lfs: true
+
+pipeline:
+  - build:
+      image: golang
+      commands: [ go build ]
+`)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	spec, _ := New(WithCloneImage("registry.corp/drone/git")).Compile(conf)
+
+	step := spec.Stages[0].Steps[0]
+	if got, want := step.Image, "registry.corp/drone/git:latest"; got != want {
+		t.Errorf("Want clone image %s, got %s", want, got)
+	}
+	for k, v := range map[string]string{
+		"PLUGIN_DEPTH":       "50",
+		"PLUGIN_TAGS":        "true",
+		"PLUGIN_RECURSIVE":   "true",
+		"PLUGIN_SKIP_VERIFY": "true",
+		"PLUGIN_LFS":         "true",
+	} {
+		if got := step.Environment[k]; got != v {
+			t.Errorf("Want clone parameter %s=%s, got %q", k, v, got)
+		}
+	}
+
+	conf.Clone.Image = "octocat/git"
+	spec, _ = New(WithCloneImage("registry.corp/drone/git")).Compile(conf)
+	if got, want := spec.Stages[0].Steps[0].Image, "docker.io/octocat/git:latest"; got != want {
+		t.Errorf("Want custom clone image %s, got %s", want, got)
+	}
+}
+
+func TestCompileWaitFor(t *testing.T) {
+	conf, err := config.ParseString(`
+pipeline:
+  - build:
+      image: golang
+      commands: [ go test ]
+
+services:
+  database:
+    image: mysql
+    wait_for: tcp://database:3306
+  cache:
+    image: redis
+    healthcheck:
+      wait_for: http://cache:8080/health
+      timeout: 30s
+`)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	spec, err := New(WithClone(false)).Compile(conf)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if got, want := len(spec.Stages), 3; got != want {
+		t.Errorf("Want %d stages, got %d", want, got)
+		return
+	}
+	step := spec.Stages[1].Steps[0]
+	if got := step.Name; !strings.HasSuffix(got, "_wait") {
+		t.Errorf("Want readiness step, got %s", got)
+	}
+	if got, want := step.Image, "docker.io/library/busybox:latest"; got != want {
+		t.Errorf("Want readiness image %s, got %s", want, got)
+	}
+	if got := spec.Stages[2].Steps[0].Name; !strings.HasSuffix(got, "_build") {
+		t.Errorf("Want build step after readiness step, got %s", got)
+	}
+
+	conf.Services["cache"].Healthcheck.WaitFor = []string{"ftp://cache"}
+	if _, err := New().Compile(conf); err == nil {
+		t.Errorf("Want error compiling invalid wait_for")
+	}
+}
+
+func TestCompileTimeout(t *testing.T) {
+	conf, err := config.ParseString(`
+timeout: 30m
+
+pipeline:
+  - build:
+      image: golang
+      commands: [ go build ]
+  - test:
+      image: golang
+      timeout: 2h
+      commands: [ go test ]
+  - notify:
+      image: plugins/slack
+      timeout: 5m
+
+services:
+  database:
+    image: mysql
+`)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	spec, err := New(WithClone(false), WithTimeout(time.Hour)).Compile(conf)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	want := []string{"", "1800", "3600", "300"}
+	for i, stage := range spec.Stages {
+		if got := stage.Steps[0].Environment["DRONE_STEP_TIMEOUT"]; got != want[i] {
+			t.Errorf("Want step %s timeout %q, got %q", stage.Steps[0].Name, want[i], got)
+		}
+	}
+	if got := time.Duration(conf.Pipeline[1]["test"].Timeout); got != 2*time.Hour {
+		t.Errorf("Want configuration not mutated, got timeout %s", got)
+	}
+}
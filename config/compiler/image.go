@@ -1,6 +1,11 @@
 package compiler
 
-import "github.com/docker/distribution/reference"
+import (
+	"path"
+	"strings"
+
+	"github.com/docker/distribution/reference"
+)
 
 // trimImage returns the short image name without tag.
 func trimImage(name string) string {
@@ -30,6 +35,39 @@ func expandImage(name string) string {
 	return named.String()
 }
 
+// rewriteImage returns the image name rewritten using the first
+// matching rule. If no rule matches the image is returned unchanged.
+func rewriteImage(image string, rules ...RewriteRule) string {
+	expanded := expandImage(image)
+	for _, rule := range rules {
+		from := expandRewrite(rule.From)
+		if strings.HasSuffix(from, "*") {
+			prefix := strings.TrimSuffix(from, "*")
+			if strings.HasPrefix(expanded, prefix) {
+				return strings.TrimSuffix(rule.To, "*") +
+					strings.TrimPrefix(expanded, prefix)
+			}
+			continue
+		}
+		if expanded == from || image == rule.From {
+			return rule.To
+		}
+		if strings.HasPrefix(expanded, from+"/") {
+			return rule.To + strings.TrimPrefix(expanded, from)
+		}
+	}
+	return image
+}
+
+// helper function expands the rewrite rule source so that the
+// short hostname matches the fully qualified image name.
+func expandRewrite(from string) string {
+	if strings.HasPrefix(from, "index.docker.io/") {
+		return "docker.io/" + strings.TrimPrefix(from, "index.docker.io/")
+	}
+	return from
+}
+
 // matchImage returns true if the image name matches
 // an image in the list. Note the image tag is not used
 // in the matching logic.
@@ -59,3 +97,36 @@ func matchHostname(image, hostname string) bool {
 	}
 	return reference.Domain(named) == hostname
 }
+
+// matchRegistry returns true if the image matches the registry
+// pattern. The pattern is a hostname, optionally followed by a
+// repository path prefix that may contain glob characters (e.g.
+// registry.corp/team-a/*). The returned score is the length of the
+// matching pattern, used to select the most specific match.
+func matchRegistry(image, pattern string) (int, bool) {
+	ref, err := reference.ParseAnyReference(image)
+	if err != nil {
+		return 0, false
+	}
+	named, err := reference.ParseNamed(ref.String())
+	if err != nil {
+		return 0, false
+	}
+
+	pattern = strings.TrimSuffix(pattern, "/*")
+	parts := strings.Split(pattern, "/")
+	if parts[0] == "index.docker.io" {
+		parts[0] = "docker.io"
+	}
+
+	name := strings.Split(reference.Domain(named)+"/"+reference.Path(named), "/")
+	if len(name) < len(parts) {
+		return 0, false
+	}
+	pattern = strings.Join(parts, "/")
+	match, err := path.Match(pattern, strings.Join(name[:len(parts)], "/"))
+	if err != nil || !match {
+		return 0, false
+	}
+	return len(pattern), true
+}
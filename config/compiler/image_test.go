@@ -256,3 +256,131 @@ func Test_matchHostname(t *testing.T) {
 		}
 	}
 }
+
+func Test_matchRegistry(t *testing.T) {
+	testdata := []struct {
+		image, pattern string
+		want           bool
+	}{
+		{
+			image:   "golang",
+			pattern: "docker.io",
+			want:    true,
+		},
+		{
+			image:   "golang",
+			pattern: "index.docker.io",
+			want:    true,
+		},
+		{
+			image:   "golang",
+			pattern: "docker.io/library/*",
+			want:    true,
+		},
+		{
+			image:   "registry.corp/team-a/app:1.0.0",
+			pattern: "registry.corp",
+			want:    true,
+		},
+		{
+			image:   "registry.corp/team-a/app:1.0.0",
+			pattern: "registry.corp/team-a/*",
+			want:    true,
+		},
+		{
+			image:   "registry.corp/team-a/sub/app:1.0.0",
+			pattern: "registry.corp/team-a",
+			want:    true,
+		},
+		{
+			image:   "registry.corp/team-b/app:1.0.0",
+			pattern: "registry.corp/team-a/*",
+			want:    false,
+		},
+		{
+			image:   "registry.corp/team-ab/app:1.0.0",
+			pattern: "registry.corp/team-a",
+			want:    false,
+		},
+		{
+			image:   "registry.corp/team-b/app:1.0.0",
+			pattern: "registry.corp/team*/app",
+			want:    true,
+		},
+		{
+			image:   "registry.corp/team-a",
+			pattern: "registry.corp/team-a/app",
+			want:    false,
+		},
+		{
+			image:   "*&^%",
+			pattern: "registry.corp",
+			want:    false,
+		},
+	}
+	for _, test := range testdata {
+		_, got := matchRegistry(test.image, test.pattern)
+		if got != test.want {
+			t.Errorf("Want image %q matching registry %q is %v", test.image, test.pattern, test.want)
+		}
+	}
+}
+
+func Test_rewriteImage(t *testing.T) {
+	rules := []RewriteRule{
+		{
+			From: "docker.io/library/*",
+			To:   "mirror.corp/dockerhub/*",
+		},
+		{
+			From: "index.docker.io/plugins/*",
+			To:   "mirror.corp/plugins/*",
+		},
+		{
+			From: "gcr.io",
+			To:   "mirror.corp/gcr",
+		},
+		{
+			From: "quay.io/coreos/etcd:v3.3.0",
+			To:   "mirror.corp/etcd:v3.3.0",
+		},
+	}
+	testdata := []struct {
+		from string
+		want string
+	}{
+		{
+			from: "golang",
+			want: "mirror.corp/dockerhub/golang:latest",
+		},
+		{
+			from: "golang:1.10",
+			want: "mirror.corp/dockerhub/golang:1.10",
+		},
+		{
+			from: "plugins/docker",
+			want: "mirror.corp/plugins/docker:latest",
+		},
+		{
+			from: "gcr.io/google-containers/pause:3.1",
+			want: "mirror.corp/gcr/google-containers/pause:3.1",
+		},
+		{
+			from: "quay.io/coreos/etcd:v3.3.0",
+			want: "mirror.corp/etcd:v3.3.0",
+		},
+		{
+			from: "octocat/hello-world",
+			want: "octocat/hello-world",
+		},
+		{
+			from: "gcr.iox/foo",
+			want: "gcr.iox/foo",
+		},
+	}
+	for _, test := range testdata {
+		if got, want := rewriteImage(test.from, rules...), test.want; got != want {
+			t.Errorf("Want image %q rewritten to %q, got %q", test.from, want, got)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package compiler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/docker/distribution/reference"
+	"github.com/drone/drone-runtime/engine"
+)
+
+type (
+	// ImageLock maps fully qualified image names to the image
+	// digest, used to pin images for reproducible builds.
+	ImageLock map[string]string
+
+	// Resolver resolves the digest of an image.
+	Resolver interface {
+		Resolve(image string) (string, error)
+	}
+
+	// ResolverFunc is an adapter to allow the use of an ordinary
+	// function as a Resolver.
+	ResolverFunc func(image string) (string, error)
+)
+
+// Resolve calls f(image).
+func (f ResolverFunc) Resolve(image string) (string, error) {
+	return f(image)
+}
+
+// DockerResolver resolves the image digest from the local docker
+// image cache. The image must be pulled before it can be resolved.
+var DockerResolver = ResolverFunc(func(image string) (string, error) {
+	stdout := new(bytes.Buffer)
+	cmd := exec.Command("docker", "image", "inspect", "--format", "{{json .RepoDigests}}", image)
+	cmd.Stdout = stdout
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("Cannot inspect image %s: %s", image, err)
+	}
+	var digests []string
+	if err := json.Unmarshal(stdout.Bytes(), &digests); err != nil {
+		return "", err
+	}
+	name := expandImage(trimImage(image))
+	for _, digest := range digests {
+		parts := strings.SplitN(digest, "@", 2)
+		if len(parts) == 2 && expandImage(trimImage(parts[0])) == name {
+			return parts[1], nil
+		}
+	}
+	return "", fmt.Errorf("Cannot resolve digest for image %s", image)
+})
+
+// GenerateLock returns an image lock for every image in the compiled
+// pipeline, including the clone step and services.
+func GenerateLock(spec *engine.Config, resolver Resolver) (ImageLock, error) {
+	lock := ImageLock{}
+	for _, stage := range spec.Stages {
+		for _, step := range stage.Steps {
+			if _, ok := lock[step.Image]; ok {
+				continue
+			}
+			if isDigested(step.Image) {
+				continue
+			}
+			digest, err := resolver.Resolve(step.Image)
+			if err != nil {
+				return nil, err
+			}
+			lock[step.Image] = digest
+		}
+	}
+	return lock, nil
+}
+
+// ParseLock parses the image lock from reader r.
+func ParseLock(r io.Reader) (ImageLock, error) {
+	out, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return ParseLockBytes(out)
+}
+
+// ParseLockBytes parses the image lock from bytes b.
+func ParseLockBytes(b []byte) (ImageLock, error) {
+	lock := ImageLock{}
+	err := json.Unmarshal(b, &lock)
+	return lock, err
+}
+
+// ParseLockFile parses the image lock from path p.
+func ParseLockFile(p string) (ImageLock, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseLock(f)
+}
+
+// pinImage returns the image name pinned to the digest in the lock.
+// If the image is already pinned to a digest it is returned unchanged.
+func pinImage(image string, lock ImageLock) (string, bool) {
+	if isDigested(image) {
+		return image, true
+	}
+	digest, ok := lock[expandImage(image)]
+	if !ok {
+		return image, false
+	}
+	named, err := reference.ParseNormalizedNamed(image)
+	if err != nil {
+		return image, false
+	}
+	return reference.TrimNamed(named).String() + "@" + digest, true
+}
+
+// helper function returns true if the image is pinned to a digest.
+func isDigested(image string) bool {
+	ref, err := reference.ParseAnyReference(image)
+	if err != nil {
+		return false
+	}
+	_, ok := ref.(reference.Digested)
+	return ok
+}
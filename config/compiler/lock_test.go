@@ -0,0 +1,129 @@
+package compiler
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/drone/drone-runtime/engine"
+	"github.com/drone/drone-yaml-v1/config"
+)
+
+const testDigest = "sha256:1e7e3e7b0a1a6b3c2a4c5e0dbe3d0b1e0b5f4b0bd5c1b6e3cc0b8a5bd5d4e1c2"
+
+func TestGenerateLock(t *testing.T) {
+	spec := &engine.Config{
+		Stages: []*engine.Stage{
+			{Steps: []*engine.Step{{Image: "docker.io/drone/git:latest"}}},
+			{Steps: []*engine.Step{{Image: "docker.io/library/mysql:5.7"}}},
+			{Steps: []*engine.Step{
+				{Image: "docker.io/library/golang:1.10"},
+				{Image: "docker.io/library/golang:1.10"},
+				{Image: "docker.io/library/alpine@" + testDigest},
+			}},
+		},
+	}
+	var resolved []string
+	resolver := ResolverFunc(func(image string) (string, error) {
+		resolved = append(resolved, image)
+		return testDigest, nil
+	})
+
+	got, err := GenerateLock(spec, resolver)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	want := ImageLock{
+		"docker.io/drone/git:latest":    testDigest,
+		"docker.io/library/mysql:5.7":   testDigest,
+		"docker.io/library/golang:1.10": testDigest,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Want image lock %v, got %v", want, got)
+	}
+	if len(resolved) != 3 {
+		t.Errorf("Want each image resolved once, got %v", resolved)
+	}
+
+	resolver = ResolverFunc(func(image string) (string, error) {
+		return "", fmt.Errorf("not found")
+	})
+	if _, err := GenerateLock(spec, resolver); err == nil {
+		t.Errorf("Want error when image cannot be resolved")
+	}
+}
+
+func Test_pinImage(t *testing.T) {
+	lock := ImageLock{
+		"docker.io/library/golang:1.10": testDigest,
+	}
+	testdata := []struct {
+		from string
+		want string
+		ok   bool
+	}{
+		{
+			from: "golang:1.10",
+			want: "docker.io/library/golang@" + testDigest,
+			ok:   true,
+		},
+		{
+			from: "docker.io/library/golang:1.10",
+			want: "docker.io/library/golang@" + testDigest,
+			ok:   true,
+		},
+		{
+			from: "golang@" + testDigest,
+			want: "golang@" + testDigest,
+			ok:   true,
+		},
+		{
+			from: "golang:1.9",
+			want: "golang:1.9",
+			ok:   false,
+		},
+	}
+	for _, test := range testdata {
+		got, ok := pinImage(test.from, lock)
+		if got != test.want || ok != test.ok {
+			t.Errorf("Want image %q pinned to %q %v, got %q %v", test.from, test.want, test.ok, got, ok)
+		}
+	}
+}
+
+func TestCompileImageLock(t *testing.T) {
+	conf, err := config.ParseString(`
+pipeline:
+  - build:
+      image: golang:1.10
+      commands: [ go build ]
+  - test:
+      image: golang:1.9
+      commands: [ go test ]
+`)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	lock := ImageLock{
+		"docker.io/library/golang:1.10": testDigest,
+	}
+
+	_, err = New(WithClone(false), WithImageLock(lock, true)).Compile(conf)
+	if err == nil {
+		t.Errorf("Want error compiling image not found in the lock file")
+	}
+
+	spec, err := New(WithClone(false), WithImageLock(lock, false)).Compile(conf)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if got, want := spec.Stages[0].Steps[0].Image, "docker.io/library/golang@"+testDigest; got != want {
+		t.Errorf("Want image pinned to %s, got %s", want, got)
+	}
+	if got, want := spec.Stages[1].Steps[0].Image, "docker.io/library/golang:1.9"; got != want {
+		t.Errorf("Want image unchanged %s, got %s", want, got)
+	}
+}
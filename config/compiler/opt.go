@@ -3,6 +3,7 @@ package compiler
 import (
 	"net/url"
 	"path/filepath"
+	"time"
 )
 
 // Option set a compiler option.
@@ -29,6 +30,33 @@ func WithClone(clone bool) Option {
 	}
 }
 
+// WithCloneImage returns a compiler option to override the default
+// clone image, for example, for air-gapped installations. The image
+// configured in the clone section of the yaml takes precedence.
+func WithCloneImage(image string) Option {
+	return func(c *Compiler) {
+		c.cloneImage = image
+	}
+}
+
+// WithPlatformTags configures the compiler to append the platform
+// image tag (e.g. linux-arm64) to plugin images in the given list of
+// namespaces (e.g. plugins), when the image tag is not specified.
+func WithPlatformTags(namespaces ...string) Option {
+	return WithTransform(
+		transformPlatformTag(namespaces...),
+	)
+}
+
+// WithTimeout configures the compiler with the maximum step
+// timeout. The maximum is used as the default timeout for steps
+// that do not define a timeout.
+func WithTimeout(max time.Duration) Option {
+	return func(c *Compiler) {
+		c.timeout = max
+	}
+}
+
 // WithVolumes configutes the compiler with default volumes that
 // are mounted to each container in the pipeline.
 func WithVolumes(volumes ...string) Option {
@@ -40,17 +68,41 @@ func WithVolumes(volumes ...string) Option {
 // WithRegistry configures the compiler with registry credentials
 // that should be used to download images.
 func WithRegistry(registries ...Registry) Option {
-	return WithTransform(
-		transformRegistry(registries...),
-	)
+	return func(c *Compiler) {
+		c.registries = append(c.registries, registries...)
+	}
+}
+
+// WithImageRewrite configures the compiler with rules used to
+// rewrite image names, for example, to pull images from a registry
+// mirror. Registry credentials are matched against the rewritten
+// image name.
+func WithImageRewrite(rules ...RewriteRule) Option {
+	return func(c *Compiler) {
+		c.rewrites = append(c.rewrites, rules...)
+	}
+}
+
+// WithImageLock configures the compiler to pin images to the digest
+// in the image lock. If strict is true, compilation fails when an
+// image is not found in the lock, otherwise the image is unchanged.
+func WithImageLock(lock ImageLock, strict bool) Option {
+	return func(c *Compiler) {
+		c.lock = lock
+		c.lockStrict = strict
+	}
 }
 
 // WithSecret configures the compiler with external secrets
-// to be injected into the container at runtime.
+// to be injected into the container at runtime. Secrets are
+// withheld from the pipeline if they do not match the event,
+// branch or fork status of the pipeline metadata.
 func WithSecret(secrets ...Secret) Option {
-	return WithTransform(
-		transformSecret(secrets...),
-	)
+	return func(c *Compiler) {
+		c.transforms = append(c.transforms,
+			transformSecret(&c.metadata, secrets...),
+		)
+	}
 }
 
 // WithNetrc configures the compiler with netrc authentication
@@ -115,7 +167,8 @@ func WithNetworks(networks ...string) Option {
 }
 
 // WithLimits configures the compiler with default resource limits that
-// are applied each container in the pipeline.
+// are applied each container in the pipeline. Resource limits defined
+// by the container are used when present, capped by the default.
 func WithLimits(limits Resources) Option {
 	return WithTransform(
 		transformLimits(limits),
@@ -0,0 +1,169 @@
+package compiler
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/drone/drone-yaml-v1/config"
+)
+
+// Path styles used by the target platform.
+const (
+	PathStylePosix   = "posix"
+	PathStyleWindows = "windows"
+)
+
+// Platform defines the target platform and the platform specific
+// defaults used when compiling the pipeline.
+type Platform struct {
+	OS      string
+	Arch    string
+	Variant string
+	Kernel  string
+
+	// CloneImage is the default image used by the clone step.
+	CloneImage string
+
+	// WaitImage is the image used by the service readiness step.
+	WaitImage string
+
+	// Tag is the image tag used by multi-platform plugin images
+	// for the platform. If empty, the default image tag is used.
+	Tag string
+
+	// NetworkDriver is the driver used by the default network.
+	NetworkDriver string
+
+	// Shell is the default shell used to execute commands.
+	Shell string
+
+	// PathStyle is the filesystem path style, posix or windows.
+	PathStyle string
+
+	// WorkspaceBase is the default workspace base path. If empty,
+	// the compiler default is converted to the platform path style.
+	WorkspaceBase string
+}
+
+var (
+	platformsMu sync.RWMutex
+	platforms   = map[string]*Platform{}
+)
+
+func init() {
+	RegisterPlatform(&Platform{
+		OS:            "linux",
+		Arch:          "amd64",
+		CloneImage:    "drone/git",
+		WaitImage:     "busybox",
+		NetworkDriver: "bridge",
+		Shell:         "/bin/sh",
+		PathStyle:     PathStylePosix,
+	})
+	RegisterPlatform(&Platform{
+		OS:            "linux",
+		Arch:          "arm",
+		CloneImage:    "drone/git:linux-arm",
+		WaitImage:     "busybox",
+		Tag:           "linux-arm",
+		NetworkDriver: "bridge",
+		Shell:         "/bin/sh",
+		PathStyle:     PathStylePosix,
+	})
+	RegisterPlatform(&Platform{
+		OS:            "linux",
+		Arch:          "arm64",
+		CloneImage:    "drone/git:linux-arm64",
+		WaitImage:     "busybox",
+		Tag:           "linux-arm64",
+		NetworkDriver: "bridge",
+		Shell:         "/bin/sh",
+		PathStyle:     PathStylePosix,
+	})
+	RegisterPlatform(&Platform{
+		OS:            "windows",
+		Arch:          "amd64",
+		CloneImage:    "drone/git:windows-1803",
+		WaitImage:     "mcr.microsoft.com/windows/servercore:1803",
+		Tag:           "windows-1803",
+		NetworkDriver: "nat",
+		Shell:         "powershell",
+		PathStyle:     PathStyleWindows,
+	})
+	RegisterPlatform(&Platform{
+		OS:            "windows",
+		Arch:          "amd64",
+		Kernel:        "1809",
+		CloneImage:    "drone/git:windows-1809",
+		WaitImage:     "mcr.microsoft.com/windows/servercore:1809",
+		Tag:           "windows-1809",
+		NetworkDriver: "nat",
+		Shell:         "powershell",
+		PathStyle:     PathStyleWindows,
+	})
+}
+
+// RegisterPlatform registers the platform defaults. A registered
+// platform with the same os, arch, variant and kernel is replaced.
+func RegisterPlatform(p *Platform) {
+	platformsMu.Lock()
+	platforms[platformKey(p.OS, p.Arch, p.Variant, p.Kernel)] = p
+	platformsMu.Unlock()
+}
+
+// LookupPlatform returns the platform defaults for the configured
+// platform. The platform may be configured by name (e.g. linux/arm64)
+// or by os, arch, variant and kernel. If the variant or kernel is
+// not registered the defaults for the os and arch are returned, and
+// if the platform is not registered the linux/amd64 defaults are
+// returned.
+func LookupPlatform(from config.Platform) *Platform {
+	os, arch, variant := from.OS, from.Arch, from.Variant
+	if from.Name != "" {
+		parts := strings.SplitN(from.Name, "/", 3)
+		os = parts[0]
+		if len(parts) > 1 {
+			arch = parts[1]
+		}
+		if len(parts) > 2 {
+			variant = parts[2]
+		}
+	}
+	if os == "" {
+		os = "linux"
+	}
+	if arch == "" {
+		arch = "amd64"
+	}
+
+	platformsMu.RLock()
+	defer platformsMu.RUnlock()
+	for _, key := range []string{
+		platformKey(os, arch, variant, from.Kernel),
+		platformKey(os, arch, variant, ""),
+		platformKey(os, arch, "", from.Kernel),
+		platformKey(os, arch, "", ""),
+	} {
+		if p, ok := platforms[key]; ok {
+			return p
+		}
+	}
+	return platforms[platformKey("linux", "amd64", "", "")]
+}
+
+// IsWindows returns true if the platform uses the windows path style.
+func (p *Platform) IsWindows() bool {
+	return p.PathStyle == PathStyleWindows
+}
+
+// helper function returns the platform registry key.
+func platformKey(os, arch, variant, kernel string) string {
+	key := strings.ToLower(os + "/" + arch)
+	if variant != "" {
+		key = key + "/" + strings.ToLower(variant)
+	}
+	if kernel != "" {
+		key = key + ":" + strings.ToLower(kernel)
+	}
+	return key
+}
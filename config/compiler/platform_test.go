@@ -0,0 +1,82 @@
+package compiler
+
+import (
+	"testing"
+
+	"github.com/drone/drone-yaml-v1/config"
+)
+
+func TestLookupPlatform(t *testing.T) {
+	testdata := []struct {
+		from  config.Platform
+		image string
+	}{
+		{
+			from:  config.Platform{},
+			image: "drone/git",
+		},
+		{
+			from:  config.Platform{Name: "linux/amd64"},
+			image: "drone/git",
+		},
+		{
+			from:  config.Platform{Name: "linux/arm/v7"},
+			image: "drone/git:linux-arm",
+		},
+		{
+			from:  config.Platform{OS: "linux", Arch: "arm64"},
+			image: "drone/git:linux-arm64",
+		},
+		{
+			from:  config.Platform{Name: "windows/amd64"},
+			image: "drone/git:windows-1803",
+		},
+		{
+			from:  config.Platform{OS: "windows", Arch: "amd64", Kernel: "1809"},
+			image: "drone/git:windows-1809",
+		},
+		{
+			from:  config.Platform{Name: "windows/amd64", Kernel: "1809"},
+			image: "drone/git:windows-1809",
+		},
+		{
+			from:  config.Platform{Name: "linux/ppc64le"},
+			image: "drone/git",
+		},
+	}
+	for _, test := range testdata {
+		if got, want := LookupPlatform(test.from).CloneImage, test.image; got != want {
+			t.Errorf("Want platform %v clone image %s, got %s", test.from, want, got)
+		}
+	}
+}
+
+func TestRegisterPlatform(t *testing.T) {
+	RegisterPlatform(&Platform{
+		OS:            "linux",
+		Arch:          "ppc64le",
+		CloneImage:    "drone/git:linux-ppc64le",
+		NetworkDriver: "bridge",
+		Shell:         "/bin/sh",
+		PathStyle:     PathStylePosix,
+	})
+	defer func() {
+		platformsMu.Lock()
+		delete(platforms, "linux/ppc64le")
+		platformsMu.Unlock()
+	}()
+
+	p := LookupPlatform(config.Platform{Name: "linux/ppc64le"})
+	if got, want := p.CloneImage, "drone/git:linux-ppc64le"; got != want {
+		t.Errorf("Want registered platform clone image %s, got %s", want, got)
+	}
+}
+
+func TestPlatformIsWindows(t *testing.T) {
+	if LookupPlatform(config.Platform{Name: "linux/amd64"}).IsWindows() {
+		t.Errorf("Want linux platform is not windows")
+	}
+	if !LookupPlatform(config.Platform{OS: "windows"}).IsWindows() {
+		t.Errorf("Want windows platform is windows")
+	}
+}
@@ -0,0 +1,165 @@
+package compiler
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+)
+
+type (
+	// dockerConfig represents the docker config.json file.
+	dockerConfig struct {
+		Auths       map[string]dockerAuth `json:"auths"`
+		CredHelpers map[string]string     `json:"credHelpers"`
+	}
+
+	// dockerAuth represents registry credentials stored in
+	// the docker config.json file.
+	dockerAuth struct {
+		Auth          string `json:"auth"`
+		Username      string `json:"username"`
+		Password      string `json:"password"`
+		Email         string `json:"email"`
+		IdentityToken string `json:"identitytoken"`
+	}
+
+	// dockerCredential represents registry credentials returned
+	// by a docker credential helper.
+	dockerCredential struct {
+		ServerURL string `json:"ServerURL"`
+		Username  string `json:"Username"`
+		Secret    string `json:"Secret"`
+	}
+)
+
+// execCredHelper executes the named docker credential helper
+// and returns the registry credentials for the hostname.
+var execCredHelper = func(helper, hostname string) ([]byte, error) {
+	stdout := new(bytes.Buffer)
+	cmd := exec.Command("docker-credential-"+helper, "get")
+	cmd.Stdin = strings.NewReader(hostname)
+	cmd.Stdout = stdout
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("docker-credential-%s: %s", helper, err)
+	}
+	return stdout.Bytes(), nil
+}
+
+// ParseDockerConfig parses the registry credentials from the docker
+// config.json file in reader r. Credentials are loaded from the auths
+// section, and from any credential helpers in the credHelpers section.
+func ParseDockerConfig(r io.Reader) ([]Registry, error) {
+	out, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return ParseDockerConfigBytes(out)
+}
+
+// ParseDockerConfigBytes parses the registry credentials from the
+// docker config.json file in bytes b.
+func ParseDockerConfigBytes(b []byte) ([]Registry, error) {
+	config := new(dockerConfig)
+	if err := json.Unmarshal(b, config); err != nil {
+		return nil, err
+	}
+
+	var registries []Registry
+	for key, auth := range config.Auths {
+		registry := Registry{
+			Hostname: normalizeHostname(key),
+			Username: auth.Username,
+			Password: auth.Password,
+			Email:    auth.Email,
+			Token:    auth.IdentityToken,
+		}
+		if auth.Auth != "" {
+			username, password, err := decodeAuth(auth.Auth)
+			if err != nil {
+				return nil, err
+			}
+			registry.Username = username
+			registry.Password = password
+		}
+		registries = append(registries, registry)
+	}
+
+	for key, helper := range config.CredHelpers {
+		out, err := execCredHelper(helper, key)
+		if err != nil {
+			return nil, err
+		}
+		cred := new(dockerCredential)
+		if err := json.Unmarshal(out, cred); err != nil {
+			return nil, err
+		}
+		registry := Registry{
+			Hostname: normalizeHostname(key),
+			Username: cred.Username,
+			Password: cred.Secret,
+		}
+		// the credential helper returns an identity token
+		// when the username is the literal <token>.
+		if cred.Username == "<token>" {
+			registry.Username = ""
+			registry.Password = ""
+			registry.Token = cred.Secret
+		}
+		registries = append(registries, registry)
+	}
+
+	// sort the registries to ensure the output is deterministic.
+	sort.Slice(registries, func(i, j int) bool {
+		return registries[i].Hostname < registries[j].Hostname
+	})
+	return registries, nil
+}
+
+// ParseDockerConfigFile parses the registry credentials from the
+// docker config.json file at path p.
+func ParseDockerConfigFile(p string) ([]Registry, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseDockerConfig(f)
+}
+
+// helper function decodes the base64 encoded username and
+// password stored in the docker config.json file.
+func decodeAuth(s string) (username, password string, err error) {
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return
+	}
+	parts := strings.SplitN(string(b), ":", 2)
+	if len(parts) != 2 {
+		err = fmt.Errorf("Invalid registry auth string")
+		return
+	}
+	return parts[0], parts[1], nil
+}
+
+// helper function returns the registry hostname from the docker
+// config.json key, which may be a hostname or a url.
+func normalizeHostname(s string) string {
+	if strings.Contains(s, "://") {
+		if uri, err := url.Parse(s); err == nil {
+			s = uri.Host
+		}
+	}
+	s = strings.SplitN(s, "/", 2)[0]
+	if s == "index.docker.io" || s == "registry-1.docker.io" {
+		s = "docker.io"
+	}
+	return s
+}
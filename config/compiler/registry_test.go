@@ -0,0 +1,85 @@
+package compiler
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kr/pretty"
+)
+
+func TestParseDockerConfig(t *testing.T) {
+	before := execCredHelper
+	defer func() {
+		execCredHelper = before
+	}()
+	execCredHelper = func(helper, hostname string) ([]byte, error) {
+		if helper != "gcr" || hostname != "gcr.io" {
+			t.Errorf("Unexpected credential helper %s for %s", helper, hostname)
+		}
+		return []byte(`{"ServerURL":"gcr.io","Username":"<token>","Secret":"ya29.a0"}`), nil
+	}
+
+	got, err := ParseDockerConfigBytes([]byte(testDockerConfig))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	want := []Registry{
+		{
+			Hostname: "docker.io",
+			Username: "octocat",
+			Password: "correct-horse-battery-staple",
+		},
+		{
+			Hostname: "gcr.io",
+			Token:    "ya29.a0",
+		},
+		{
+			Hostname: "quay.io",
+			Token:    "eyJhbGciOiJSUzI1NiJ9",
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Unexpected registry credentials")
+		pretty.Ldiff(t, want, got)
+	}
+}
+
+func TestParseDockerConfigError(t *testing.T) {
+	_, err := ParseDockerConfigBytes([]byte(`{"auths":{"docker.io":{"auth":"b2N0b2NhdA=="}}}`))
+	if err == nil {
+		t.Errorf("Expect error parsing invalid auth string")
+	}
+}
+
+func Test_normalizeHostname(t *testing.T) {
+	tests := []struct {
+		before string
+		after  string
+	}{
+		{"https://index.docker.io/v1/", "docker.io"},
+		{"index.docker.io", "docker.io"},
+		{"gcr.io", "gcr.io"},
+		{"https://registry.corp:5000", "registry.corp:5000"},
+		{"registry.corp/v2/", "registry.corp"},
+	}
+	for _, test := range tests {
+		if got, want := normalizeHostname(test.before), test.after; got != want {
+			t.Errorf("Want hostname %s, got %s", want, got)
+		}
+	}
+}
+
+var testDockerConfig = `{
+	"auths": {
+		"https://index.docker.io/v1/": {
+			"auth": "b2N0b2NhdDpjb3JyZWN0LWhvcnNlLWJhdHRlcnktc3RhcGxl"
+		},
+		"quay.io": {
+			"identitytoken": "eyJhbGciOiJSUzI1NiJ9"
+		}
+	},
+	"credHelpers": {
+		"gcr.io": "gcr"
+	}
+}`
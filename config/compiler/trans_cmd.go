@@ -5,6 +5,7 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"strconv"
 	"strings"
 	"time"
 
@@ -16,7 +17,8 @@ import (
 )
 
 func transformCommand(dst *engine.Step, src *yaml.Container, conf *config.Config) {
-	if conf.Platform.Name == "windows/amd64" {
+	platform := LookupPlatform(conf.Platform)
+	if platform.IsWindows() {
 		transformCommandWin(dst, src, conf)
 		return
 	}
@@ -25,10 +27,10 @@ func transformCommand(dst *engine.Step, src *yaml.Container, conf *config.Config
 	}
 	shell := src.Shell
 	if len(src.Shell) == 0 {
-		shell = "/bin/sh"
+		shell = platform.Shell
 	}
 
-	script := generateScriptPosix(src.Commands)
+	script := generateScriptPosix(src.Commands, time.Duration(src.Timeout), calcRetry(dst, src))
 	dst.Entrypoint = []string{shell}
 	dst.Command = []string{"/bin/_drone"}
 	dst.Restore = append(dst.Restore, &engine.Snapshot{
@@ -38,8 +40,10 @@ func transformCommand(dst *engine.Step, src *yaml.Container, conf *config.Config
 }
 
 // generateScriptPosix is a helper function that generates a build script
-// for a linux container using the given
-func generateScriptPosix(commands []string) string {
+// for a linux container using the given commands. If the retry attempts
+// are set the commands are retried, and if the timeout is set the script
+// is terminated by a watchdog once the timeout is exceeded.
+func generateScriptPosix(commands []string, timeout time.Duration, retry yaml.Retry) string {
 	var buf bytes.Buffer
 	for _, command := range commands {
 		escaped := fmt.Sprintf("%q", command)
@@ -50,9 +54,27 @@ func generateScriptPosix(commands []string) string {
 			command,
 		))
 	}
+	body := buf.String()
+	if retry.Attempts > 1 {
+		body = fmt.Sprintf(
+			retryScript,
+			body,
+			generateExitCodesPosix(retry.OnExitCodes),
+			retry.Attempts,
+			int64(time.Duration(retry.Delay)/time.Second),
+		)
+	}
+	if timeout > 0 {
+		body = fmt.Sprintf(
+			watchdogScript,
+			int64(timeout/time.Second),
+			timeout,
+			body,
+		)
+	}
 	script := fmt.Sprintf(
 		setupScript,
-		buf.String(),
+		body,
 	)
 
 	w := new(bytes.Buffer)
@@ -96,3 +118,61 @@ const traceScript = `
 echo + %s
 %s
 `
+
+// helper function returns the retry policy. Detached steps are
+// not retried.
+func calcRetry(dst *engine.Step, src *yaml.Container) yaml.Retry {
+	if dst.Detached {
+		return yaml.Retry{}
+	}
+	return src.Retry
+}
+
+// helper function generates the case statement that exits the
+// retry loop if the exit code is not in the list of exit codes.
+func generateExitCodesPosix(codes []int) string {
+	if len(codes) == 0 {
+		return ""
+	}
+	var patterns []string
+	for _, code := range codes {
+		patterns = append(patterns, strconv.Itoa(code))
+	}
+	return fmt.Sprintf(
+		"case $code in %s) ;; *) exit $code ;; esac\n",
+		strings.Join(patterns, "|"),
+	)
+}
+
+// retryScript is a helper script that is added to the build script
+// to retry the commands. The commands are executed in a subshell
+// so that the script continues when the commands fail.
+const retryScript = `
+set +e
+attempt=1
+while true; do
+(
+set -e
+%s
+)
+code=$?
+if [ $code -eq 0 ]; then break; fi
+%sif [ $attempt -ge %[3]d ]; then exit $code; fi
+attempt=$((attempt+1))
+echo "+ retry $attempt/%[3]d"
+sleep %d
+done
+set -e
+`
+
+// watchdogScript is a helper script that is added to the build script
+// to terminate the commands once the timeout is exceeded. The commands
+// are executed in a subshell so that they are terminated even if the
+// build script is the container init process.
+const watchdogScript = `
+( sleep %d; echo "+ step timeout of %s exceeded"; kill -TERM -1 ) &
+trap "kill $! 2>/dev/null" EXIT
+(
+%s
+)
+`
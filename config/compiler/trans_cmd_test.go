@@ -1 +1,74 @@
 package compiler
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/drone/drone-yaml-v1/yaml"
+
+	"github.com/vincent-petithory/dataurl"
+)
+
+func Test_generateScriptPosix_Timeout(t *testing.T) {
+	data, err := dataurl.DecodeString(generateScriptPosix([]string{"go test"}, 15*time.Minute, yaml.Retry{}))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if !strings.Contains(string(data.Data), "( sleep 900;") {
+		t.Errorf("Want watchdog in the build script")
+	}
+
+	data, _ = dataurl.DecodeString(generateScriptPosix([]string{"go test"}, 0, yaml.Retry{}))
+	if strings.Contains(string(data.Data), "sleep") {
+		t.Errorf("Want no watchdog in the build script without a timeout")
+	}
+}
+
+func Test_generateScriptWindows_Timeout(t *testing.T) {
+	data, _ := base64.StdEncoding.DecodeString(generateScriptWindows([]string{"go test"}, 15*time.Minute, yaml.Retry{}))
+	if !strings.Contains(string(data), "Start-Sleep -Seconds 900;") {
+		t.Errorf("Want watchdog in the build script")
+	}
+}
+
+func Test_generateScriptPosix_Retry(t *testing.T) {
+	retry := yaml.Retry{
+		Attempts:    3,
+		Delay:       yaml.Duration(10 * time.Second),
+		OnExitCodes: []int{1, 137},
+	}
+	data, err := dataurl.DecodeString(generateScriptPosix([]string{"go test"}, 0, retry))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	for _, want := range []string{
+		"case $code in 1|137) ;; *) exit $code ;; esac",
+		"if [ $attempt -ge 3 ]; then exit $code; fi",
+		`echo "+ retry $attempt/3"`,
+		"sleep 10",
+	} {
+		if !strings.Contains(string(data.Data), want) {
+			t.Errorf("Want %q in the build script", want)
+		}
+	}
+}
+
+func Test_generateScriptWindows_Retry(t *testing.T) {
+	retry := yaml.Retry{
+		Attempts:    3,
+		OnExitCodes: []int{1, 137},
+	}
+	data, _ := base64.StdEncoding.DecodeString(generateScriptWindows([]string{"go test"}, 0, retry))
+	for _, want := range []string{
+		"if ($attempt -ge 3 -or @(1, 137) -notcontains $code) { exit $code };",
+		`Write-Output "+ retry $attempt/3";`,
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("Want %q in the build script", want)
+		}
+	}
+}
@@ -4,7 +4,9 @@ import (
 	"bytes"
 	"encoding/base64"
 	"fmt"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/drone/drone-runtime/engine"
 	"github.com/drone/drone-yaml-v1/config"
@@ -19,14 +21,14 @@ func transformCommandWin(dst *engine.Step, src *yaml.Container, conf *config.Con
 		dst.Environment = map[string]string{}
 	}
 
-	dst.Entrypoint = []string{"powershell", "-noprofile", "-noninteractive", "-command"}
+	dst.Entrypoint = []string{LookupPlatform(conf.Platform).Shell, "-noprofile", "-noninteractive", "-command"}
 	dst.Command = []string{"[System.Text.Encoding]::UTF8.GetString([System.Convert]::FromBase64String($Env:CI_SCRIPT)) | iex"}
-	dst.Environment["CI_SCRIPT"] = generateScriptWindows(src.Commands)
+	dst.Environment["CI_SCRIPT"] = generateScriptWindows(src.Commands, time.Duration(src.Timeout), calcRetry(dst, src))
 	dst.Environment["HOME"] = "c:\\root"
 	dst.Environment["SHELL"] = "powershell.exe"
 }
 
-func generateScriptWindows(commands []string) string {
+func generateScriptWindows(commands []string, timeout time.Duration, retry yaml.Retry) string {
 	var buf bytes.Buffer
 	for _, command := range commands {
 		escaped := fmt.Sprintf("%q", command)
@@ -37,16 +39,50 @@ func generateScriptWindows(commands []string) string {
 			command,
 		))
 	}
+	body := buf.String()
+	if retry.Attempts > 1 {
+		// the commands are written to a script file and executed
+		// in a child process, so that a failed command exits the
+		// child process and not the retry loop.
+		body = fmt.Sprintf(
+			retryScriptWin,
+			base64.StdEncoding.EncodeToString([]byte(errorPreferenceWin+body)),
+			retry.Attempts,
+			generateExitCodesWin(retry.OnExitCodes),
+			retry.Attempts,
+			int64(time.Duration(retry.Delay)/time.Second),
+		)
+	}
+	if timeout > 0 {
+		body = fmt.Sprintf(
+			watchdogScriptWin,
+			int64(timeout/time.Second),
+		) + body
+	}
 	script := fmt.Sprintf(
 		setupScriptWin,
-		buf.String(),
+		body,
 	)
 	return base64.StdEncoding.EncodeToString([]byte(script))
 }
 
-const setupScriptWin = `
-$ErrorActionPreference = 'Stop';
-&cmd /c "mkdir c:\root";
+// helper function generates the condition that exits the retry
+// loop if the exit code is not in the list of exit codes.
+func generateExitCodesWin(codes []int) string {
+	if len(codes) == 0 {
+		return ""
+	}
+	var patterns []string
+	for _, code := range codes {
+		patterns = append(patterns, strconv.Itoa(code))
+	}
+	return fmt.Sprintf(
+		" -or @(%s) -notcontains $code",
+		strings.Join(patterns, ", "),
+	)
+}
+
+const setupScriptWin = errorPreferenceWin + `&cmd /c "mkdir c:\root";
 if ($Env:CI_NETRC_MACHINE) {
 $netrc=[string]::Format("{0}\_netrc",$Env:HOME);
 "machine $Env:CI_NETRC_MACHINE" >> $netrc;
@@ -66,3 +102,33 @@ const traceScriptWin = `
 Write-Output ('+ %s');
 & %s; if ($LASTEXITCODE -ne 0) {exit $LASTEXITCODE}
 `
+
+// watchdogScriptWin is a helper script that is added to the build
+// script to terminate the build script once the timeout is exceeded.
+const watchdogScriptWin = `
+$watchdog = Start-Job -ScriptBlock { Start-Sleep -Seconds %d; Stop-Process -Id $using:PID -Force };
+`
+
+// errorPreferenceWin is a helper script that is added to the build
+// script to stop on the first error.
+const errorPreferenceWin = `
+$ErrorActionPreference = 'Stop';
+`
+
+// retryScriptWin is a helper script that is added to the build script
+// to retry the commands.
+const retryScriptWin = `
+$script = [System.Text.Encoding]::UTF8.GetString([System.Convert]::FromBase64String('%s'));
+$file = Join-Path ([System.IO.Path]::GetTempPath()) 'drone-script.ps1';
+Set-Content -Path $file -Value $script;
+$attempt = 1;
+while ($true) {
+& (Get-Process -Id $PID).Path -noprofile -noninteractive -file $file;
+$code = $LASTEXITCODE;
+if ($code -eq 0) { break };
+if ($attempt -ge %d%s) { exit $code };
+$attempt++;
+Write-Output "+ retry $attempt/%d";
+Start-Sleep -Seconds %d;
+};
+`
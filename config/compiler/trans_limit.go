@@ -6,13 +6,27 @@ import (
 	"github.com/drone/drone-yaml-v1/yaml"
 )
 
+// transformLimits applies the resource limits to the container. If the
+// container defines a resource limit it is capped by the maximum,
+// otherwise the maximum is used as the default.
 func transformLimits(limits Resources) Transform {
 	return func(dst *engine.Step, src *yaml.Container, conf *config.Config) {
-		dst.MemSwapLimit = limits.MemSwapLimit
-		dst.MemLimit = limits.MemLimit
-		dst.ShmSize = limits.ShmSize
-		dst.CPUQuota = limits.CPUQuota
-		dst.CPUShares = limits.CPUShares
-		dst.CPUSet = limits.CPUSet
+		dst.MemSwapLimit = capLimit(dst.MemSwapLimit, limits.MemSwapLimit)
+		dst.MemLimit = capLimit(dst.MemLimit, limits.MemLimit)
+		dst.ShmSize = capLimit(dst.ShmSize, limits.ShmSize)
+		dst.CPUQuota = capLimit(dst.CPUQuota, limits.CPUQuota)
+		dst.CPUShares = capLimit(dst.CPUShares, limits.CPUShares)
+		if dst.CPUSet == "" || (limits.CPUSet != "" && !yaml.CPUSetWithin(dst.CPUSet, limits.CPUSet)) {
+			dst.CPUSet = limits.CPUSet
+		}
 	}
 }
+
+// helper function returns the value capped by the maximum. If
+// the value is not set the maximum is returned.
+func capLimit(value, max int64) int64 {
+	if value == 0 || (max != 0 && value > max) {
+		return max
+	}
+	return value
+}
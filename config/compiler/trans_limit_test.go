@@ -37,3 +37,38 @@ func Test_transformLimits(t *testing.T) {
 		t.Errorf("Want CPUSet %v, got %v", want, got)
 	}
 }
+
+func Test_transformLimitsCeiling(t *testing.T) {
+	src := new(yaml.Container)
+	dst := new(engine.Step)
+	dst.MemLimit = 1024
+	dst.CPUQuota = 20000
+	dst.CPUShares = 512
+	dst.CPUSet = "4,5"
+
+	limits := Resources{
+		MemLimit:  2048,
+		CPUQuota:  10000,
+		CPUShares: 1024,
+		CPUSet:    "0-3",
+		ShmSize:   64,
+	}
+
+	transformLimits(limits)(dst, src, nil)
+
+	if got, want := dst.MemLimit, int64(1024); got != want {
+		t.Errorf("Want MemLimit %v below the ceiling, got %v", want, got)
+	}
+	if got, want := dst.CPUQuota, limits.CPUQuota; got != want {
+		t.Errorf("Want CPUQuota capped at %v, got %v", want, got)
+	}
+	if got, want := dst.CPUShares, int64(512); got != want {
+		t.Errorf("Want CPUShares %v below the ceiling, got %v", want, got)
+	}
+	if got, want := dst.CPUSet, limits.CPUSet; got != want {
+		t.Errorf("Want CPUSet outside the ceiling replaced with %v, got %v", want, got)
+	}
+	if got, want := dst.ShmSize, limits.ShmSize; got != want {
+		t.Errorf("Want ShmSize default %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,40 @@
+package compiler
+
+import (
+	"strings"
+
+	"github.com/drone/drone-runtime/engine"
+	"github.com/drone/drone-yaml-v1/config"
+	"github.com/drone/drone-yaml-v1/yaml"
+
+	"github.com/docker/distribution/reference"
+)
+
+// transformPlatformTag appends the platform image tag to plugin images
+// in the list of namespaces, when the image tag is not specified.
+func transformPlatformTag(namespaces ...string) Transform {
+	return func(dst *engine.Step, src *yaml.Container, conf *config.Config) {
+		tag := LookupPlatform(conf.Platform).Tag
+		if tag == "" {
+			return
+		}
+		ref, err := reference.ParseNormalizedNamed(src.Image)
+		if err != nil {
+			return
+		}
+		if _, ok := ref.(reference.Tagged); ok {
+			return
+		}
+		if _, ok := ref.(reference.Digested); ok {
+			return
+		}
+		name := reference.FamiliarName(ref)
+		for _, namespace := range namespaces {
+			namespace = strings.TrimSuffix(namespace, "/")
+			if name == namespace || strings.HasPrefix(name, namespace+"/") {
+				dst.Image = expandImage(name + ":" + tag)
+				return
+			}
+		}
+	}
+}
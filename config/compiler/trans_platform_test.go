@@ -0,0 +1,58 @@
+package compiler
+
+import (
+	"testing"
+
+	"github.com/drone/drone-runtime/engine"
+	"github.com/drone/drone-yaml-v1/config"
+	"github.com/drone/drone-yaml-v1/yaml"
+)
+
+func Test_transformPlatformTag(t *testing.T) {
+	testdatum := []struct {
+		platform string
+		image    string
+		want     string
+	}{
+		{
+			platform: "linux/arm64",
+			image:    "plugins/slack",
+			want:     "docker.io/plugins/slack:linux-arm64",
+		},
+		{
+			platform: "windows/amd64",
+			image:    "plugins/docker",
+			want:     "docker.io/plugins/docker:windows-1803",
+		},
+		// the image tag is specified
+		{
+			platform: "linux/arm64",
+			image:    "plugins/slack:1.0",
+			want:     "docker.io/plugins/slack:1.0",
+		},
+		// the image is not in the plugin namespaces
+		{
+			platform: "linux/arm64",
+			image:    "golang",
+			want:     "docker.io/library/golang:latest",
+		},
+		// the platform does not use a platform tag
+		{
+			platform: "linux/amd64",
+			image:    "plugins/slack",
+			want:     "docker.io/plugins/slack:latest",
+		},
+	}
+
+	for _, testdata := range testdatum {
+		src := &yaml.Container{Image: testdata.image}
+		dst := &engine.Step{Image: expandImage(testdata.image)}
+		conf := &config.Config{Platform: config.Platform{Name: testdata.platform}}
+
+		transformPlatformTag("plugins", "octocat/")(dst, src, conf)
+
+		if got, want := dst.Image, testdata.want; got != want {
+			t.Errorf("Want image %s on %s, got %s", want, testdata.platform, got)
+		}
+	}
+}
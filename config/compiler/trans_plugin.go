@@ -27,6 +27,11 @@ func paramsToEnv(from map[string]interface{}, to map[string]string) error {
 		if v == nil {
 			continue
 		}
+		// parameters sourced from secrets are injected by
+		// the secret transform.
+		if _, ok := yaml.FromSecret(v); ok {
+			continue
+		}
 
 		t := reflect.TypeOf(v)
 		vv := reflect.ValueOf(v)
@@ -6,15 +6,26 @@ import (
 	"github.com/drone/drone-yaml-v1/yaml"
 )
 
+// transformRegistry configures the registry credentials used to pull
+// the container image. The registry hostname may include a repository
+// path prefix, in which case the most specific match is used.
 func transformRegistry(registries ...Registry) Transform {
 	return func(dst *engine.Step, _ *yaml.Container, _ *config.Config) {
-		for _, registry := range registries {
-			if matchHostname(dst.Image, registry.Hostname) {
-				dst.AuthConfig.Username = registry.Username
-				dst.AuthConfig.Password = registry.Password
-				dst.AuthConfig.Email = registry.Email
-				break
+		var match *Registry
+		var score int
+		for i, registry := range registries {
+			n, ok := matchRegistry(dst.Image, registry.Hostname)
+			if ok && n > score {
+				match = &registries[i]
+				score = n
 			}
 		}
+		if match == nil {
+			return
+		}
+		dst.AuthConfig.Username = match.Username
+		dst.AuthConfig.Password = match.Password
+		dst.AuthConfig.Email = match.Email
+		dst.AuthConfig.Token = match.Token
 	}
 }
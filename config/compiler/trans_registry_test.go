@@ -65,3 +65,56 @@ func Test_transformRegistry(t *testing.T) {
 		}
 	}
 }
+
+func Test_transformRegistryLongestMatch(t *testing.T) {
+	registries := []Registry{
+		{
+			Hostname: "registry.corp",
+			Username: "default",
+			Password: "password",
+		},
+		{
+			Hostname: "registry.corp/team-a/*",
+			Token:    "eyJhbGciOiJSUzI1NiJ9",
+		},
+		{
+			Hostname: "registry.corp/team-a/frontend/*",
+			Username: "frontend",
+			Password: "password",
+		},
+	}
+
+	testdatum := []struct {
+		image    string
+		username string
+		token    string
+	}{
+		{
+			image:    "registry.corp/team-b/app",
+			username: "default",
+		},
+		{
+			image: "registry.corp/team-a/app",
+			token: "eyJhbGciOiJSUzI1NiJ9",
+		},
+		{
+			image:    "registry.corp/team-a/frontend/app",
+			username: "frontend",
+		},
+	}
+
+	for _, testdata := range testdatum {
+		src := new(yaml.Container)
+		dst := new(engine.Step)
+		dst.Image = testdata.image
+
+		transformRegistry(registries...)(dst, src, nil)
+
+		if got, want := dst.AuthConfig.Username, testdata.username; got != want {
+			t.Errorf("Expect registry username %q for image %s, got %q", want, testdata.image, got)
+		}
+		if got, want := dst.AuthConfig.Token, testdata.token; got != want {
+			t.Errorf("Expect registry token %q for image %s, got %q", want, testdata.image, got)
+		}
+	}
+}
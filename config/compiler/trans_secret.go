@@ -6,25 +6,72 @@ import (
 	"github.com/drone/drone-runtime/engine"
 	"github.com/drone/drone-yaml-v1/config"
 	"github.com/drone/drone-yaml-v1/yaml"
+
+	"github.com/vincent-petithory/dataurl"
 )
 
-func transformSecret(secrets ...Secret) Transform {
+// secretFileMode is the default file mode used when a secret is
+// mounted as a file and the mode is not specified.
+const secretFileMode = 0600
+
+// Allowed returns true if the secret can be exposed to a pipeline
+// with the given metadata. Secrets are never exposed to pull
+// requests from forked repositories unless explicitly enabled.
+func (s *Secret) Allowed(metadata Metadata) bool {
+	if metadata.Fork && !s.Fork {
+		return false
+	}
+	return s.Event.Match(metadata.Event) &&
+		s.Branch.Match(metadata.Branch)
+}
+
+// WithheldSecrets returns the list of secrets that are withheld
+// from a pipeline with the given metadata.
+func WithheldSecrets(metadata Metadata, secrets ...Secret) []Secret {
+	var withheld []Secret
+	for _, secret := range secrets {
+		if !secret.Allowed(metadata) {
+			withheld = append(withheld, secret)
+		}
+	}
+	return withheld
+}
+
+func transformSecret(metadata *Metadata, secrets ...Secret) Transform {
 	lookup := map[string]Secret{}
 	for _, secret := range secrets {
 		lookup[strings.ToLower(secret.Name)] = secret
 	}
 
+	// helper function returns the named secret if it exists
+	// and the container is granted access.
+	find := func(name, image string) (Secret, bool) {
+		secret, ok := lookup[strings.ToLower(name)]
+		if !ok {
+			return secret, false
+		}
+		if metadata != nil && !secret.Allowed(*metadata) {
+			return secret, false
+		}
+		if len(secret.Match) != 0 && !matchImage(image, secret.Match...) {
+			return secret, false
+		}
+		return secret, true
+	}
+
 	return func(dst *engine.Step, src *yaml.Container, _ *config.Config) {
 		var injected []string
 		for _, value := range src.Secrets.Secrets {
-			secret, ok := lookup[strings.ToLower(value.Source)]
+			secret, ok := find(value.Source, dst.Image)
 			if !ok {
 				continue
 			}
 
-			if len(secret.Match) != 0 && !matchImage(dst.Image, secret.Match...) {
+			if value.IsFile() {
+				injectSecretFile(dst, value, secret)
 				continue
 			}
+
 			injected = append(injected, value.Target)
 			dst.Secrets = append(dst.Secrets, &engine.Secret{
 				Name:  strings.ToUpper(value.Target),
@@ -32,6 +79,31 @@ func transformSecret(secrets ...Secret) Transform {
 				Mask:  true,
 			})
 		}
+
+		// inject environment variables and plugin parameters
+		// that reference a secret using from_secret.
+		refs := map[string]string{}
+		for k, name := range src.Environment.Secrets {
+			refs[k] = name
+		}
+		for k, v := range src.Vargs {
+			if name, ok := yaml.FromSecret(v); ok {
+				refs["PLUGIN_"+strings.ToUpper(k)] = name
+			}
+		}
+		for k, name := range refs {
+			secret, ok := find(name, dst.Image)
+			if !ok {
+				continue
+			}
+			injected = append(injected, k)
+			dst.Secrets = append(dst.Secrets, &engine.Secret{
+				Name:  k,
+				Value: secret.Value,
+				Mask:  true,
+			})
+		}
+
 		if len(injected) == 0 {
 			return
 		}
@@ -41,3 +113,23 @@ func transformSecret(secrets ...Secret) Transform {
 		dst.Environment["DRONE_SECRETS"] = strings.Join(injected, ",")
 	}
 }
+
+// helper function restores the secret value to the target file path
+// in the container. The secret is registered without a name so that
+// the runtime masks the value in the logs, but does not expose the
+// value as an environment variable.
+func injectSecretFile(dst *engine.Step, value *yaml.Secret, secret Secret) {
+	mode := value.Mode
+	if mode == 0 {
+		mode = secretFileMode
+	}
+	tarball := generateTarball(value.Target, secret.Value, mode)
+	dst.Restore = append(dst.Restore, &engine.Snapshot{
+		Data:   []byte(dataurl.EncodeBytes(tarball)),
+		Target: "/",
+	})
+	dst.Secrets = append(dst.Secrets, &engine.Secret{
+		Value: secret.Value,
+		Mask:  true,
+	})
+}
@@ -93,7 +93,7 @@ func Test_transformSecret(t *testing.T) {
 			Match: testdata.match,
 		}
 
-		transformSecret(secret)(dst, src, nil)
+		transformSecret(nil, secret)(dst, src, nil)
 
 		if !testdata.matched {
 			if len(dst.Secrets) != 0 {
@@ -122,3 +122,169 @@ func Test_transformSecret(t *testing.T) {
 		}
 	}
 }
+
+func Test_transformSecretFile(t *testing.T) {
+	src := new(yaml.Container)
+	src.Secrets.Secrets = []*yaml.Secret{
+		{
+			Source: "kubeconfig",
+			Target: "/root/.kube/config",
+			Type:   "file",
+		},
+	}
+
+	dst := new(engine.Step)
+	dst.Image = "golang"
+
+	secret := Secret{
+		Name:  "kubeconfig",
+		Value: "apiVersion: v1",
+	}
+
+	transformSecret(nil, secret)(dst, src, nil)
+
+	if len(dst.Restore) != 1 {
+		t.Errorf("Expect secret file restored to the container")
+		return
+	}
+	if got, want := dst.Restore[0].Target, "/"; got != want {
+		t.Errorf("Expect secret file restored to %s, got %s", want, got)
+	}
+	if len(dst.Secrets) != 1 {
+		t.Errorf("Expect secret file registered for masking")
+		return
+	}
+	if got, want := dst.Secrets[0].Name, ""; got != want {
+		t.Errorf("Expect secret file not exposed as variable, got %s", got)
+	}
+	if !dst.Secrets[0].Mask {
+		t.Errorf("Expect secret file value masked")
+	}
+	if _, ok := dst.Environment["DRONE_SECRETS"]; ok {
+		t.Errorf("Expect secret file not listed in DRONE_SECRETS")
+	}
+}
+
+func Test_transformSecretConstraints(t *testing.T) {
+	testdatum := []struct {
+		secret   Secret
+		metadata Metadata
+		matched  bool
+	}{
+		{
+			secret:   Secret{Name: "password", Value: "correct-horse-battery-staple"},
+			metadata: Metadata{Event: "push", Branch: "master"},
+			matched:  true,
+		},
+		// the secret is never exposed to forks unless enabled
+		{
+			secret:   Secret{Name: "password", Value: "correct-horse-battery-staple"},
+			metadata: Metadata{Event: "pull_request", Branch: "master", Fork: true},
+			matched:  false,
+		},
+		{
+			secret:   Secret{Name: "password", Value: "correct-horse-battery-staple", Fork: true},
+			metadata: Metadata{Event: "pull_request", Branch: "master", Fork: true},
+			matched:  true,
+		},
+		// the secret is restricted by event
+		{
+			secret: Secret{
+				Name:  "password",
+				Value: "correct-horse-battery-staple",
+				Event: yaml.Constraint{Include: []string{"push", "tag"}},
+			},
+			metadata: Metadata{Event: "pull_request", Branch: "master"},
+			matched:  false,
+		},
+		// the secret is restricted by branch
+		{
+			secret: Secret{
+				Name:   "password",
+				Value:  "correct-horse-battery-staple",
+				Branch: yaml.Constraint{Include: []string{"main"}},
+			},
+			metadata: Metadata{Event: "push", Branch: "feature/foo"},
+			matched:  false,
+		},
+		{
+			secret: Secret{
+				Name:   "password",
+				Value:  "correct-horse-battery-staple",
+				Branch: yaml.Constraint{Include: []string{"main"}},
+			},
+			metadata: Metadata{Event: "push", Branch: "main"},
+			matched:  true,
+		},
+	}
+
+	for _, testdata := range testdatum {
+		src := new(yaml.Container)
+		src.Secrets.Secrets = []*yaml.Secret{
+			{
+				Source: "password",
+				Target: "PASSWORD",
+			},
+		}
+
+		dst := new(engine.Step)
+		dst.Image = "golang"
+
+		transformSecret(&testdata.metadata, testdata.secret)(dst, src, nil)
+
+		if got, want := len(dst.Secrets) != 0, testdata.matched; got != want {
+			t.Errorf("Want secret granted is %v for metadata %v, got %v",
+				want,
+				testdata.metadata,
+				got,
+			)
+		}
+		if got, want := len(WithheldSecrets(testdata.metadata, testdata.secret)) == 0, testdata.matched; got != want {
+			t.Errorf("Want secret withheld is %v for metadata %v", !want, testdata.metadata)
+		}
+	}
+}
+
+func Test_transformSecretFromSecret(t *testing.T) {
+	src := new(yaml.Container)
+	src.Environment.Secrets = map[string]string{
+		"DOCKER_USERNAME": "docker_username",
+	}
+	src.Vargs = map[string]interface{}{
+		"password": map[interface{}]interface{}{
+			"from_secret": "docker_password",
+		},
+		"repo": "octocat/hello-world",
+	}
+
+	dst := new(engine.Step)
+	dst.Image = "plugins/docker"
+
+	secrets := []Secret{
+		{Name: "docker_username", Value: "octocat"},
+		{Name: "docker_password", Value: "correct-horse-battery-staple"},
+	}
+
+	transformPlugin(dst, src, nil)
+	transformSecret(nil, secrets...)(dst, src, nil)
+
+	got := map[string]string{}
+	for _, secret := range dst.Secrets {
+		if !secret.Mask {
+			t.Errorf("Expect secret %s masked", secret.Name)
+		}
+		got[secret.Name] = secret.Value
+	}
+	if got, want := got["DOCKER_USERNAME"], "octocat"; got != want {
+		t.Errorf("Expect environment secret DOCKER_USERNAME=%s, got %s", want, got)
+	}
+	if got, want := got["PLUGIN_PASSWORD"], "correct-horse-battery-staple"; got != want {
+		t.Errorf("Expect plugin secret PLUGIN_PASSWORD=%s, got %s", want, got)
+	}
+	if _, ok := dst.Environment["PLUGIN_PASSWORD"]; ok {
+		t.Errorf("Expect secret reference not converted to plugin parameter")
+	}
+	if got, want := dst.Environment["PLUGIN_REPO"], "octocat/hello-world"; got != want {
+		t.Errorf("Expect plugin parameter PLUGIN_REPO=%s, got %s", want, got)
+	}
+}
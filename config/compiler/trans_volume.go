@@ -18,7 +18,7 @@ func transformVolume(volume ...string) Transform {
 				target string
 			)
 			var parts []string
-			if conf.Platform.Name == "windows/amd64" {
+			if LookupPlatform(conf.Platform).IsWindows() {
 				parts = splitVolumeParts(mapping)
 			} else {
 				parts = strings.Split(mapping, ":")
@@ -13,17 +13,21 @@ import (
 
 func transformWorkspace(defaultBase, defaultPath string) Transform {
 	return func(dst *engine.Step, src *yaml.Container, conf *config.Config) {
+		platform := LookupPlatform(conf.Platform)
 		workdirBase := conf.Workspace.Base
 		workdirPath := conf.Workspace.Path
 		if workdirBase == "" {
-			if conf.Platform.Name == "windows/amd64" {
+			switch {
+			case platform.WorkspaceBase != "":
+				workdirBase = platform.WorkspaceBase
+			case platform.IsWindows():
 				workdirBase = toWindowsDrive(defaultBase)
-			} else {
+			default:
 				workdirBase = defaultBase
 			}
 		}
 		if workdirPath == "" {
-			if conf.Platform.Name == "windows/amd64" {
+			if platform.IsWindows() {
 				workdirPath = toWindowsPath(defaultPath)
 			} else {
 				workdirPath = defaultPath
@@ -0,0 +1,130 @@
+package compiler
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/drone/drone-yaml-v1/config"
+	"github.com/drone/drone-yaml-v1/yaml"
+)
+
+// default service readiness timeout and interval.
+const (
+	defaultWaitTimeout  = time.Minute
+	defaultWaitInterval = 2 * time.Second
+)
+
+// helper function returns the service readiness container, which
+// waits for the service endpoints to accept connections. A nil
+// container is returned if the services do not define endpoints.
+func waitContainer(conf *config.Config, services []*yaml.Container) (*yaml.Container, error) {
+	sort.Slice(services, func(i, j int) bool {
+		return services[i].Name < services[j].Name
+	})
+
+	platform := LookupPlatform(conf.Platform)
+
+	var commands []string
+	for _, service := range services {
+		timeout := time.Duration(service.Healthcheck.Timeout)
+		if timeout <= 0 {
+			timeout = defaultWaitTimeout
+		}
+		interval := time.Duration(service.Healthcheck.Interval)
+		if interval <= 0 {
+			interval = defaultWaitInterval
+		}
+		targets := append([]string{}, service.WaitFor...)
+		targets = append(targets, service.Healthcheck.WaitFor...)
+		for _, target := range targets {
+			command, err := waitCommand(platform, target, timeout, interval)
+			if err != nil {
+				return nil, err
+			}
+			commands = append(commands, command)
+		}
+	}
+	if len(commands) == 0 {
+		return nil, nil
+	}
+	return &yaml.Container{
+		Name:     "wait",
+		Image:    platform.WaitImage,
+		Commands: commands,
+	}, nil
+}
+
+// helper function returns the command that waits for the endpoint
+// to accept connections. The endpoint is a tcp or http url, and the
+// tcp scheme may be omitted (e.g. database:3306).
+func waitCommand(platform *Platform, target string, timeout, interval time.Duration) (string, error) {
+	endpoint := target
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "tcp://" + endpoint
+	}
+	uri, err := url.Parse(endpoint)
+	if err != nil {
+		return "", fmt.Errorf("Invalid wait_for %s", target)
+	}
+
+	var probe string
+	switch uri.Scheme {
+	case "tcp":
+		host, port, err := net.SplitHostPort(uri.Host)
+		if err != nil || host == "" || port == "" {
+			return "", fmt.Errorf("Invalid wait_for %s", target)
+		}
+		if platform.IsWindows() {
+			probe = fmt.Sprintf("(New-Object Net.Sockets.TcpClient(%s, %s)).Close()", quoteWin(host), quoteWin(port))
+		} else {
+			probe = fmt.Sprintf("nc -z %s %s", quotePosix(host), quotePosix(port))
+		}
+	case "http", "https":
+		if uri.Host == "" {
+			return "", fmt.Errorf("Invalid wait_for %s", target)
+		}
+		if platform.IsWindows() {
+			probe = fmt.Sprintf("Invoke-WebRequest -UseBasicParsing -Uri %s | Out-Null", quoteWin(endpoint))
+		} else {
+			probe = fmt.Sprintf("wget -q -O /dev/null %s", quotePosix(endpoint))
+		}
+	default:
+		return "", fmt.Errorf("Invalid wait_for %s", target)
+	}
+
+	seconds := int(timeout / time.Second)
+	step := int(interval / time.Second)
+	if step < 1 {
+		step = 1
+	}
+	message := "Timeout waiting for " + endpoint
+	if platform.IsWindows() {
+		return fmt.Sprintf(waitScriptWin, probe, step, seconds, quoteWin(message), step), nil
+	}
+	return fmt.Sprintf(waitScript, probe, step, seconds, quotePosix(message), step), nil
+}
+
+// helper function quotes the string for a posix shell.
+func quotePosix(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
+
+// helper function quotes the string for powershell.
+func quoteWin(s string) string {
+	s = strings.Replace(s, "`", "``", -1)
+	s = strings.Replace(s, `"`, "`\"", -1)
+	s = strings.Replace(s, "$", "`$", -1)
+	return `"` + s + `"`
+}
+
+// waitScript is a helper script that waits for the probe to succeed,
+// and exits with an error once the timeout is exceeded.
+const waitScript = `n=0; until %s; do n=$((n+%d)); if [ $n -ge %d ]; then echo %s; exit 1; fi; sleep %d; done`
+
+// waitScriptWin is a helper script that waits for the probe to
+// succeed, and throws an error once the timeout is exceeded.
+const waitScriptWin = `{ $n = 0; while ($true) { try { %s; break } catch { $n += %d; if ($n -ge %d) { throw %s }; Start-Sleep -Seconds %d } }; $global:LASTEXITCODE = 0 }`
@@ -0,0 +1,70 @@
+package compiler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/drone/drone-yaml-v1/config"
+	"github.com/drone/drone-yaml-v1/yaml"
+)
+
+func Test_waitContainer(t *testing.T) {
+	conf := &config.Config{}
+	services := []*yaml.Container{
+		{Name: "database", WaitFor: []string{"database:3306"}},
+		{Name: "cache", Healthcheck: yaml.Healthcheck{
+			WaitFor:  []string{"http://cache:8080/health"},
+			Timeout:  yaml.Duration(10 * time.Second),
+			Interval: yaml.Duration(5 * time.Second),
+		}},
+	}
+	src, err := waitContainer(conf, services)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	want := []string{
+		`n=0; until wget -q -O /dev/null 'http://cache:8080/health'; do n=$((n+5)); if [ $n -ge 10 ]; then echo 'Timeout waiting for http://cache:8080/health'; exit 1; fi; sleep 5; done`,
+		`n=0; until nc -z 'database' '3306'; do n=$((n+2)); if [ $n -ge 60 ]; then echo 'Timeout waiting for tcp://database:3306'; exit 1; fi; sleep 2; done`,
+	}
+	if len(src.Commands) != len(want) {
+		t.Errorf("Want %d commands, got %d", len(want), len(src.Commands))
+		return
+	}
+	for i := range want {
+		if got := src.Commands[i]; got != want[i] {
+			t.Errorf("Want command %s, got %s", want[i], got)
+		}
+	}
+
+	src, _ = waitContainer(conf, []*yaml.Container{{Name: "database"}})
+	if src != nil {
+		t.Errorf("Want nil container when services do not define endpoints")
+	}
+}
+
+func Test_waitCommandWin(t *testing.T) {
+	platform := LookupPlatform(config.Platform{Name: "windows/amd64"})
+	got, err := waitCommand(platform, "tcp://database:3306", time.Minute, 2*time.Second)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	want := `{ $n = 0; while ($true) { try { (New-Object Net.Sockets.TcpClient("database", "3306")).Close(); break } catch { $n += 2; if ($n -ge 60) { throw "Timeout waiting for tcp://database:3306" }; Start-Sleep -Seconds 2 } }; $global:LASTEXITCODE = 0 }`
+	if got != want {
+		t.Errorf("Want command %s, got %s", want, got)
+	}
+}
+
+func Test_waitCommandError(t *testing.T) {
+	platform := LookupPlatform(config.Platform{})
+	for _, target := range []string{
+		"database",
+		"ftp://database:21",
+		"http://",
+	} {
+		if _, err := waitCommand(platform, target, time.Minute, time.Second); err == nil {
+			t.Errorf("Want error for wait_for %s", target)
+		}
+	}
+}
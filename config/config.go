@@ -10,6 +10,7 @@ type (
 		Clone     Clone
 		Workspace Workspace
 		Version   yaml.StringInt
+		Timeout   yaml.Duration
 		DependsOn yaml.StringSlice `yaml:"depends_on"`
 		Trigger   yaml.Constraints
 		Labels    yaml.SliceMap
@@ -65,7 +66,12 @@ type (
 
 	// Clone provides clone customization
 	Clone struct {
-		Disable bool
-		Depth   int
+		Disable    bool
+		Depth      int
+		Tags       bool
+		Recursive  bool
+		SkipVerify bool `yaml:"skip_verify"`
+		LFS        bool `yaml:"lfs"`
+		Image      string
 	}
 )
@@ -2,6 +2,7 @@ package linter
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/drone/drone-yaml-v1/config"
 	"github.com/drone/drone-yaml-v1/yaml"
@@ -12,6 +13,17 @@ import (
 // Check returns an error if the configuration is invalid.
 type Check func(*config.Config) error
 
+// Resources defines the maximum container resource limits. A zero
+// value indicates there is no maximum.
+type Resources struct {
+	MemSwapLimit int64
+	MemLimit     int64
+	ShmSize      int64
+	CPUQuota     int64
+	CPUShares    int64
+	CPUSet       string
+}
+
 // CheckContainer is an adapter to perform a check for every container
 // in the configuration. If a check fails the function halts and returns
 // an error.
@@ -74,6 +86,40 @@ func CheckVolumes(trusted bool) Check {
 	}
 }
 
+// CheckDriverOpts prevents a configuration from defining volume or
+// network driver options in untrusted mode, unless the driver is in
+// the list of allowed drivers. The local volume driver is used when
+// no driver is specified.
+func CheckDriverOpts(trusted bool, drivers ...string) Check {
+	allowed := func(driver string) bool {
+		if driver == "" {
+			driver = "local"
+		}
+		for _, name := range drivers {
+			if name == driver {
+				return true
+			}
+		}
+		return false
+	}
+	return func(conf *config.Config) error {
+		if trusted {
+			return nil
+		}
+		for _, volume := range conf.Volumes {
+			if len(volume.DriverOpts) != 0 && !allowed(volume.Driver) {
+				return fmt.Errorf("Insufficient privileges to use volume driver_opts")
+			}
+		}
+		for _, network := range conf.Networks {
+			if len(network.DriverOpts) != 0 && !allowed(network.Driver) {
+				return fmt.Errorf("Insufficient privileges to use network driver_opts")
+			}
+		}
+		return nil
+	}
+}
+
 // CheckImage checks the container image attribute is not empty.
 func CheckImage(conf *config.Config, container *yaml.Container) error {
 	if len(container.Image) == 0 {
@@ -116,6 +162,99 @@ func CheckCommand(conf *config.Config, container *yaml.Container) error {
 	return nil
 }
 
+// CheckResources checks that the container resource limits do not
+// exceed the maximum resource limits.
+func CheckResources(limits Resources) Check {
+	return CheckContainer(func(conf *config.Config, container *yaml.Container) error {
+		switch {
+		case exceeds(int64(container.MemLimit), limits.MemLimit):
+			return fmt.Errorf("Cannot set mem_limit greater than %d", limits.MemLimit)
+		case exceeds(int64(container.MemSwapLimit), limits.MemSwapLimit):
+			return fmt.Errorf("Cannot set memswap_limit greater than %d", limits.MemSwapLimit)
+		case exceeds(int64(container.ShmSize), limits.ShmSize):
+			return fmt.Errorf("Cannot set shm_size greater than %d", limits.ShmSize)
+		case exceeds(int64(container.CPUQuota), limits.CPUQuota):
+			return fmt.Errorf("Cannot set cpu_quota greater than %d", limits.CPUQuota)
+		case exceeds(int64(container.CPUShares), limits.CPUShares):
+			return fmt.Errorf("Cannot set cpu_shares greater than %d", limits.CPUShares)
+		}
+		if container.CPUSet != "" && limits.CPUSet != "" &&
+			!yaml.CPUSetWithin(container.CPUSet, limits.CPUSet) {
+			return fmt.Errorf("Cannot set cpuset outside of %s", limits.CPUSet)
+		}
+		return nil
+	})
+}
+
+// helper function returns true if the value exceeds the maximum.
+func exceeds(value, max int64) bool {
+	return max != 0 && value > max
+}
+
+// CheckFromSecret checks that environment variables and plugin
+// parameters only reference known secrets using from_secret. A
+// secret is known if it is in the list of secret names, or is
+// declared in the secrets section of the configuration.
+func CheckFromSecret(names ...string) func(*config.Config, *yaml.Container) error {
+	known := map[string]struct{}{}
+	for _, name := range names {
+		known[strings.ToLower(name)] = struct{}{}
+	}
+	return func(conf *config.Config, container *yaml.Container) error {
+		var refs []string
+		for _, name := range container.Environment.Secrets {
+			refs = append(refs, name)
+		}
+		for _, v := range container.Vargs {
+			if name, ok := yaml.FromSecret(v); ok {
+				refs = append(refs, name)
+			}
+		}
+		for _, name := range refs {
+			if _, ok := known[strings.ToLower(name)]; ok {
+				continue
+			}
+			if _, ok := conf.Secrets[name]; ok {
+				continue
+			}
+			return fmt.Errorf("Invalid or missing secret %s", name)
+		}
+		return nil
+	}
+}
+
+// DefaultCapabilities defines the default list of linux capabilities
+// that can be added to a container in untrusted mode.
+var DefaultCapabilities = []string{
+	"SYS_PTRACE",
+}
+
+// CheckCapabilities checks that a container only adds linux
+// capabilities in the allowed list in untrusted mode. Dropping
+// capabilities is always permitted.
+func CheckCapabilities(trusted bool, allowed ...string) Check {
+	lookup := map[string]struct{}{}
+	for _, capability := range allowed {
+		lookup[normalizeCapability(capability)] = struct{}{}
+	}
+	return CheckContainer(func(conf *config.Config, container *yaml.Container) error {
+		if trusted {
+			return nil
+		}
+		for _, capability := range container.CapAdd {
+			if _, ok := lookup[normalizeCapability(capability)]; !ok {
+				return fmt.Errorf("Insufficient privileges to use cap_add %s", capability)
+			}
+		}
+		return nil
+	})
+}
+
+// helper function normalizes the linux capability name.
+func normalizeCapability(s string) string {
+	return strings.TrimPrefix(strings.ToUpper(s), "CAP_")
+}
+
 // CheckTrusted checks that a container is not using any restricted
 // settings that require elevated permissions.
 func CheckTrusted(trusted bool) Check {
@@ -155,6 +294,9 @@ func CheckTrusted(trusted bool) Check {
 		}
 		if len(container.Volumes) != 0 {
 			for _, volume := range container.Volumes {
+				if volume.Type == yaml.VolumeTypeTmpfs {
+					continue
+				}
 				if !IsDataVolume(conf, volume) {
 					return fmt.Errorf("Insufficient privileges to use volumes")
 				}
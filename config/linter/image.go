@@ -0,0 +1,94 @@
+package linter
+
+import (
+	"fmt"
+
+	"github.com/drone/drone-yaml-v1/config"
+	"github.com/drone/drone-yaml-v1/yaml"
+
+	"github.com/bmatcuk/doublestar"
+	"github.com/docker/distribution/reference"
+)
+
+// ImagePolicy defines the images a pipeline is permitted to use.
+// Image patterns are glob patterns matched against the short and
+// fully qualified image name, with and without the image tag.
+type ImagePolicy struct {
+	// Allow is the list of image patterns that are permitted in
+	// untrusted mode. If empty, all images are permitted.
+	Allow []string
+
+	// Deny is the list of image patterns that are never permitted.
+	Deny []string
+
+	// DenyLatest prevents images with the latest tag, or without
+	// a tag, in untrusted mode.
+	DenyLatest bool
+
+	// RequireDigest requires images are pinned to a digest in
+	// untrusted mode.
+	RequireDigest bool
+}
+
+// CheckImagePolicy checks the container images against the image
+// policy. The deny list is always enforced, while the remaining
+// rules are only enforced in untrusted mode.
+func CheckImagePolicy(trusted bool, policy ImagePolicy) Check {
+	return CheckContainer(func(conf *config.Config, container *yaml.Container) error {
+		ref, err := reference.ParseNormalizedNamed(container.Image)
+		if err != nil {
+			return fmt.Errorf("Invalid image %s", container.Image)
+		}
+		names := imageNames(ref)
+		if matchImagePatterns(names, policy.Deny) {
+			return fmt.Errorf("Insufficient privileges to use image %s", container.Image)
+		}
+		if trusted {
+			return nil
+		}
+		if len(policy.Allow) != 0 && !matchImagePatterns(names, policy.Allow) {
+			return fmt.Errorf("Insufficient privileges to use image %s", container.Image)
+		}
+		_, digested := ref.(reference.Digested)
+		if policy.RequireDigest && !digested {
+			return fmt.Errorf("Cannot use image %s without a digest", container.Image)
+		}
+		if policy.DenyLatest && !digested {
+			tagged, ok := ref.(reference.Tagged)
+			if !ok || tagged.Tag() == "latest" {
+				return fmt.Errorf("Cannot use image %s with the latest tag", container.Image)
+			}
+		}
+		return nil
+	})
+}
+
+// helper function returns the short and fully qualified image
+// names, with and without the image tag, used for pattern matching.
+func imageNames(ref reference.Named) []string {
+	trimmed := reference.TrimNamed(ref)
+	names := []string{
+		trimmed.String(),
+		reference.FamiliarName(trimmed),
+	}
+	if tagged, ok := ref.(reference.Tagged); ok {
+		names = append(names,
+			trimmed.String()+":"+tagged.Tag(),
+			reference.FamiliarName(trimmed)+":"+tagged.Tag(),
+		)
+	}
+	return names
+}
+
+// helper function returns true if any of the image names match
+// any of the patterns.
+func matchImagePatterns(names, patterns []string) bool {
+	for _, pattern := range patterns {
+		for _, name := range names {
+			if ok, _ := doublestar.Match(pattern, name); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
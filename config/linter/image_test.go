@@ -0,0 +1,92 @@
+package linter
+
+import (
+	"testing"
+
+	"github.com/drone/drone-yaml-v1/config"
+)
+
+func TestCheckImagePolicy(t *testing.T) {
+	policy := ImagePolicy{
+		Allow:         []string{"registry.corp/**", "golang"},
+		Deny:          []string{"registry.corp/legacy/**", "golang:1.4"},
+		DenyLatest:    true,
+		RequireDigest: false,
+	}
+
+	testdata := []struct {
+		image   string
+		trusted bool
+		want    string
+	}{
+		{
+			image: "registry.corp/team-a/app:1.0.0",
+		},
+		{
+			image: "golang:1.10",
+		},
+		{
+			image: "golang@sha256:1e7e3e7b0a1a6b3c2a4c5e0dbe3d0b1e0b5f4b0bd5c1b6e3cc0b8a5bd5d4e1c2",
+		},
+		{
+			image: "node:8",
+			want:  "Insufficient privileges to use image node:8",
+		},
+		{
+			image:   "node:8",
+			trusted: true,
+		},
+		{
+			image:   "registry.corp/legacy/app:1.0.0",
+			trusted: true,
+			want:    "Insufficient privileges to use image registry.corp/legacy/app:1.0.0",
+		},
+		{
+			image: "golang:1.4",
+			want:  "Insufficient privileges to use image golang:1.4",
+		},
+		{
+			image: "golang",
+			want:  "Cannot use image golang with the latest tag",
+		},
+		{
+			image: "registry.corp/team-a/app:latest",
+			want:  "Cannot use image registry.corp/team-a/app:latest with the latest tag",
+		},
+		{
+			image: "Invalid:Image",
+			want:  "Invalid image Invalid:Image",
+		},
+	}
+
+	for _, test := range testdata {
+		conf, err := config.ParseString("pipeline: [ build: { image: '" + test.image + "' } ]")
+		if err != nil {
+			t.Fatalf("Cannot unmarshal yaml for image %q. Error: %s", test.image, err)
+		}
+		lerr := New(CheckImagePolicy(test.trusted, policy)).Lint(conf)
+		if test.want == "" {
+			if lerr != nil {
+				t.Errorf("Expected lint returns no errors for image %q, got %q", test.image, lerr)
+			}
+		} else if lerr == nil {
+			t.Errorf("Expected lint error for image %q", test.image)
+		} else if lerr.Error() != test.want {
+			t.Errorf("Want error %q, got %q", test.want, lerr.Error())
+		}
+	}
+}
+
+func TestCheckImagePolicyDigest(t *testing.T) {
+	policy := ImagePolicy{RequireDigest: true}
+
+	conf, _ := config.ParseString("pipeline: [ build: { image: 'golang:1.10' } ]")
+	if err := New(CheckImagePolicy(false, policy)).Lint(conf); err == nil {
+		t.Errorf("Expected lint error for image without digest")
+	}
+
+	conf, _ = config.ParseString("pipeline: [ build: { image: 'golang@sha256:1e7e3e7b0a1a6b3c2a4c5e0dbe3d0b1e0b5f4b0bd5c1b6e3cc0b8a5bd5d4e1c2' } ]")
+	if err := New(CheckImagePolicy(false, policy)).Lint(conf); err != nil {
+		t.Errorf("Expected lint returns no errors for image with digest, got %q", err)
+	}
+}
@@ -16,8 +16,10 @@ func NewDefault(trusted bool) *Linter {
 		CheckContainer(CheckEntrypoint),
 		CheckContainer(CheckImage),
 		CheckTrusted(trusted),
+		CheckCapabilities(trusted, DefaultCapabilities...),
 		CheckVolumes(trusted),
 		CheckNetworks(trusted),
+		CheckDriverOpts(trusted),
 	)
 }
 
@@ -57,6 +57,10 @@ func TestLintErrors(t *testing.T) {
 			from: "{ pipeline: [ build: { image: 'golang' }  ], networks: { custom: { driver: overlay } } }",
 			want: "Insufficient privileges to define custom networks",
 		},
+		{
+			from: "{ pipeline: [ build: { image: 'golang' }  ], volumes: { custom: { driver_opts: { type: nfs, o: 'addr=10.0.0.1', device: ':/exports' } } } }",
+			want: "Insufficient privileges to use volume driver_opts",
+		},
 		//
 		// pipeline containers
 		//
@@ -110,6 +114,15 @@ func TestLintErrors(t *testing.T) {
 			want: "Insufficient privileges to use sysctls",
 		},
 
+		{
+			from: "pipeline: [ build: { image: golang, cap_add: [ NET_ADMIN ] } ]",
+			want: "Insufficient privileges to use cap_add NET_ADMIN",
+		},
+		{
+			from: "pipeline: [ build: { image: golang, cap_add: [ SYS_PTRACE, ALL ] } ]",
+			want: "Insufficient privileges to use cap_add ALL",
+		},
+
 		//
 		// cannot override entypoint, command for script steps
 		//
@@ -148,3 +161,146 @@ func TestLintErrors(t *testing.T) {
 		}
 	}
 }
+
+func TestLintFromSecret(t *testing.T) {
+	testdata := []struct {
+		from string
+		want string
+	}{
+		{
+			from: "pipeline: [ publish: { image: plugins/docker, password: { from_secret: docker_password } } ]",
+		},
+		{
+			from: "pipeline: [ build: { image: golang, environment: { TOKEN: { from_secret: github_token } } } ]",
+		},
+		{
+			from: "{ pipeline: [ build: { image: golang, environment: { KEY: { from_secret: ssh_key } } } ], secrets: { ssh_key: { external: true } } }",
+		},
+		{
+			from: "pipeline: [ publish: { image: plugins/docker, password: { from_secret: quay_password } } ]",
+			want: "Invalid or missing secret quay_password",
+		},
+		{
+			from: "pipeline: [ build: { image: golang, environment: { TOKEN: { from_secret: gitlab_token } } } ]",
+			want: "Invalid or missing secret gitlab_token",
+		},
+	}
+
+	for _, test := range testdata {
+		conf, err := config.ParseString(test.from)
+		if err != nil {
+			t.Fatalf("Cannot unmarshal yaml %q. Error: %s", test.from, err)
+		}
+
+		lerr := New(CheckContainer(CheckFromSecret("docker_password", "GITHUB_TOKEN"))).Lint(conf)
+		if test.want == "" {
+			if lerr != nil {
+				t.Errorf("Expected lint returns no errors, got %q", lerr)
+			}
+		} else if lerr == nil {
+			t.Errorf("Expected lint error for configuration %q", test.from)
+		} else if lerr.Error() != test.want {
+			t.Errorf("Want error %q, got %q", test.want, lerr.Error())
+		}
+	}
+}
+
+func TestLintResources(t *testing.T) {
+	limits := Resources{
+		MemLimit:  1073741824,
+		CPUQuota:  10000,
+		CPUShares: 1024,
+		CPUSet:    "0-3",
+	}
+
+	testdata := []struct {
+		from string
+		want string
+	}{
+		{
+			from: "pipeline: [ build: { image: golang, mem_limit: 512mb, cpu_quota: 5000, cpuset: '0,1' } ]",
+		},
+		{
+			from: "pipeline: [ build: { image: golang, mem_limit: 2gb } ]",
+			want: "Cannot set mem_limit greater than 1073741824",
+		},
+		{
+			from: "pipeline: [ build: { image: golang, cpu_quota: 20000 } ]",
+			want: "Cannot set cpu_quota greater than 10000",
+		},
+		{
+			from: "pipeline: [ build: { image: golang, cpu_shares: 2048 } ]",
+			want: "Cannot set cpu_shares greater than 1024",
+		},
+		{
+			from: "pipeline: [ build: { image: golang, cpuset: '2-5' } ]",
+			want: "Cannot set cpuset outside of 0-3",
+		},
+	}
+
+	for _, test := range testdata {
+		conf, err := config.ParseString(test.from)
+		if err != nil {
+			t.Fatalf("Cannot unmarshal yaml %q. Error: %s", test.from, err)
+		}
+
+		lerr := New(CheckResources(limits)).Lint(conf)
+		if test.want == "" {
+			if lerr != nil {
+				t.Errorf("Expected lint returns no errors, got %q", lerr)
+			}
+		} else if lerr == nil {
+			t.Errorf("Expected lint error for configuration %q", test.from)
+		} else if lerr.Error() != test.want {
+			t.Errorf("Want error %q, got %q", test.want, lerr.Error())
+		}
+	}
+}
+
+func TestLintCapabilities(t *testing.T) {
+	testdata := "pipeline: [ build: { image: golang, cap_add: [ SYS_PTRACE, cap_net_raw ], cap_drop: [ ALL ] } ]"
+
+	conf, err := config.ParseString(testdata)
+	if err != nil {
+		t.Fatalf("Cannot unmarshal yaml %q. Error: %s", testdata, err)
+	}
+	if err := NewDefault(false).Lint(conf); err == nil {
+		t.Errorf("Expected lint error adding capability not in the default list")
+	}
+	if err := New(CheckCapabilities(false, "SYS_PTRACE", "NET_RAW")).Lint(conf); err != nil {
+		t.Errorf("Expected lint returns no errors, got %q", err)
+	}
+	if err := New(CheckCapabilities(true)).Lint(conf); err != nil {
+		t.Errorf("Expected lint returns no errors in trusted mode, got %q", err)
+	}
+}
+
+func TestLintTmpfsVolume(t *testing.T) {
+	testdata := "pipeline: [ build: { image: golang, volumes: [ { type: tmpfs, target: /tmp } ] } ]"
+
+	conf, err := config.ParseString(testdata)
+	if err != nil {
+		t.Fatalf("Cannot unmarshal yaml %q. Error: %s", testdata, err)
+	}
+	if err := NewDefault(false).Lint(conf); err != nil {
+		t.Errorf("Expected lint returns no errors for tmpfs volume, got %q", err)
+	}
+}
+
+func TestLintDriverOpts(t *testing.T) {
+	testdata := "{ pipeline: [ build: { image: 'golang' }  ], volumes: { custom: { driver: local, driver_opts: { type: tmpfs, device: tmpfs } } } }"
+
+	conf, err := config.ParseString(testdata)
+	if err != nil {
+		t.Fatalf("Cannot unmarshal yaml %q. Error: %s", testdata, err)
+	}
+	if err := New(CheckDriverOpts(false, "local")).Lint(conf); err != nil {
+		t.Errorf("Expected lint returns no errors for allowed driver, got %q", err)
+	}
+	if err := New(CheckDriverOpts(true)).Lint(conf); err != nil {
+		t.Errorf("Expected lint returns no errors in trusted mode, got %q", err)
+	}
+	if err := New(CheckDriverOpts(false, "vieux/sshfs")).Lint(conf); err == nil {
+		t.Errorf("Expected lint error for driver not in the allowed list")
+	}
+}
@@ -22,7 +22,9 @@ func TestParse(t *testing.T) {
 			Path: "src/github.com/octocat/hello-world",
 		},
 		Clone: Clone{
-			Depth: 50,
+			Depth:      50,
+			Tags:       true,
+			SkipVerify: true,
 		},
 		Networks: map[string]Network{
 			"custom": {Driver: "overlay"},
@@ -89,6 +91,8 @@ workspace:
 
 clone:
   depth: 50
+  tags: true
+  skip_verify: true
 
 pipeline:
   - test:
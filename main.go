@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"log"
 	"os"
+	"sort"
+	"strings"
 
 	"github.com/alecthomas/kingpin"
 	"github.com/drone/drone-yaml-v1/config"
@@ -17,14 +19,29 @@ import (
 var tty = isatty.IsTerminal(os.Stdout.Fd())
 
 var (
-	source       = kingpin.Arg("source", "source file location").Required().File()
-	target       = kingpin.Arg("target", "target file location").String()
+	compileCmd = kingpin.Command("compile", "compile the configuration").Default()
+	source     = compileCmd.Arg("source", "source file location").Required().File()
+	target     = compileCmd.Arg("target", "target file location").String()
+	lockfile   = compileCmd.Flag("lock", "image lock file location").PlaceHolder(".drone.lock").String()
+	lockstrict = compileCmd.Flag("lock-strict", "fail if an image is not in the lock file").Bool()
+
+	lockCmd    = kingpin.Command("lock", "write the image lock file")
+	lockSource = lockCmd.Arg("source", "source file location").Required().File()
+	lockTarget = lockCmd.Arg("target", "lock file location").Default(".drone.lock").String()
+)
+
+var (
 	trusted      = kingpin.Flag("trusted", "trusted mode").Bool()
 	clone        = kingpin.Flag("clone", "clone step").Bool()
+	cloneImage   = kingpin.Flag("clone-image", "clone step image").PlaceHolder("drone/git").String()
 	volume       = kingpin.Flag("volume", "attached volumes").Strings()
 	network      = kingpin.Flag("network", "attached networks").Strings()
 	environ      = kingpin.Flag("env", "environment variable").StringMap()
 	images       = kingpin.Flag("privileged", "privileged images").Default("plugins/docker").Strings()
+	imageAllow   = kingpin.Flag("image-allow", "allowed image patterns").Strings()
+	imageDeny    = kingpin.Flag("image-deny", "denied image patterns").Strings()
+	denyLatest   = kingpin.Flag("image-deny-latest", "deny images with the latest tag").Bool()
+	digest       = kingpin.Flag("image-require-digest", "require images pinned to a digest").Bool()
 	base         = kingpin.Flag("base", "workspace base path").Default("/workspace").String()
 	path         = kingpin.Flag("path", "wrokspace path").String()
 	event        = kingpin.Flag("event", "event type").PlaceHolder("<event>").Enum("push", "pull_request", "tag", "deployment")
@@ -32,9 +49,13 @@ var (
 	branch       = kingpin.Flag("git-branch", "git commit branch").PlaceHolder("master").String()
 	ref          = kingpin.Flag("git-ref", "git commit ref").PlaceHolder("refs/heads/master").String()
 	deploy       = kingpin.Flag("deploy-to", "target deployment").PlaceHolder("production").String()
+	fork         = kingpin.Flag("fork", "pull request from a fork").Bool()
 	platform     = kingpin.Flag("platform", "target platform").PlaceHolder("linux/amd64").String()
+	platformTags = kingpin.Flag("platform-tags", "plugin namespaces with platform image tags").PlaceHolder("plugins").Strings()
 	secrets      = kingpin.Flag("secret", "secret variable").StringMap()
 	registries   = kingpin.Flag("registry", "registry credentials").URLList()
+	rewrites     = kingpin.Flag("image-rewrite", "image rewrite rules").PlaceHolder("docker.io/*=mirror.corp/*").StringMap()
+	dockerconf   = kingpin.Flag("docker-config", "docker config.json with registry credentials").PlaceHolder("~/.docker/config.json").String()
 	username     = kingpin.Flag("netrc-login", "netrc username").PlaceHolder("<token>").String()
 	password     = kingpin.Flag("netrc-password", "netrc password").PlaceHolder("x-oauth-basic").String()
 	machine      = kingpin.Flag("netrc-machine", "netrc machine").PlaceHolder("github.com").String()
@@ -44,13 +65,19 @@ var (
 	memlimit     = kingpin.Flag("mem-limit", "memory limit").PlaceHolder("1GB").Bytes()
 	memswaplimit = kingpin.Flag("mem-swap-limit", "memory swap limit").PlaceHolder("1GB").Bytes()
 	shmsize      = kingpin.Flag("shmsize", "shmsize").PlaceHolder("1GB").Bytes()
+	timeout      = kingpin.Flag("timeout", "maximum step timeout").PlaceHolder("1h").Duration()
 )
 
 func main() {
 	kingpin.Version(version.Version.String())
-	kingpin.Parse()
+	cmd := kingpin.Parse()
+
+	in := *source
+	if cmd == lockCmd.FullCommand() {
+		in = *lockSource
+	}
 
-	conf, err := config.Parse(*source)
+	conf, err := config.Parse(in)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -59,12 +86,56 @@ func main() {
 		log.Fatal(err)
 	}
 
+	policy := linter.ImagePolicy{
+		Allow:         *imageAllow,
+		Deny:          *imageDeny,
+		DenyLatest:    *denyLatest,
+		RequireDigest: *digest,
+	}
+	if err := linter.New(linter.CheckImagePolicy(*trusted, policy)).Lint(conf); err != nil {
+		log.Fatal(err)
+	}
+
+	limits := linter.Resources{
+		CPUQuota:     *cpuquota,
+		CPUShares:    *cpushares,
+		CPUSet:       *cpuset,
+		ShmSize:      int64(*shmsize),
+		MemLimit:     int64(*memlimit),
+		MemSwapLimit: int64(*memswaplimit),
+	}
+	if err := linter.New(linter.CheckResources(limits)).Lint(conf); err != nil {
+		log.Fatal(err)
+	}
+
 	var secretList []compiler.Secret
+	var secretNames []string
 	for k, v := range *secrets {
 		secretList = append(secretList, compiler.Secret{
 			Name:  k,
 			Value: v,
 		})
+		secretNames = append(secretNames, k)
+	}
+
+	if err := linter.New(
+		linter.CheckContainer(linter.CheckFromSecret(secretNames...)),
+	).Lint(conf); err != nil {
+		log.Fatal(err)
+	}
+
+	metadata := compiler.Metadata{
+		Branch:      *branch,
+		Event:       *event,
+		Ref:         *ref,
+		Repo:        *repo,
+		Platform:    *platform,
+		Environment: *deploy,
+		Fork:        *fork,
+	}
+
+	for _, secret := range compiler.WithheldSecrets(metadata, secretList...) {
+		log.Printf("Secret %s withheld from the pipeline", secret.Name)
 	}
 
 	var registryList []compiler.Registry
@@ -77,14 +148,36 @@ func main() {
 			log.Fatalln("Invalid or missing registry password")
 		}
 		registryList = append(registryList, compiler.Registry{
-			Hostname: uri.Host,
+			Hostname: uri.Host + strings.TrimSuffix(uri.Path, "/"),
 			Username: uri.User.Username(),
 			Password: password,
 		})
 	}
 
+	if *dockerconf != "" {
+		list, err := compiler.ParseDockerConfigFile(*dockerconf)
+		if err != nil {
+			log.Fatal(err)
+		}
+		registryList = append(registryList, list...)
+	}
+
+	var rewriteList []compiler.RewriteRule
+	for k, v := range *rewrites {
+		rewriteList = append(rewriteList, compiler.RewriteRule{
+			From: k,
+			To:   v,
+		})
+	}
+	// flags are unordered, so the most specific rule is
+	// evaluated first.
+	sort.Slice(rewriteList, func(i, j int) bool {
+		return len(rewriteList[i].From) > len(rewriteList[j].From)
+	})
+
 	var opts = []compiler.Option{
 		compiler.WithClone(*clone),
+		compiler.WithCloneImage(*cloneImage),
 		compiler.WithEnviron(*environ),
 		compiler.WithLimits(
 			compiler.Resources{
@@ -96,26 +189,60 @@ func main() {
 				MemSwapLimit: int64(*memswaplimit),
 			},
 		),
-		compiler.WithMetadata(
-			compiler.Metadata{
-				Branch:      *branch,
-				Event:       *event,
-				Ref:         *ref,
-				Repo:        *repo,
-				Platform:    *platform,
-				Environment: *deploy,
-			},
-		),
+		compiler.WithMetadata(metadata),
 		compiler.WithNetrc(*username, *password, *machine),
 		compiler.WithNetworks(*network...),
 		compiler.WithPrivileged(*images...),
+		compiler.WithPlatformTags(*platformTags...),
+		compiler.WithTimeout(*timeout),
 		compiler.WithRegistry(registryList...),
+		compiler.WithImageRewrite(rewriteList...),
 		compiler.WithSecret(secretList...),
 		compiler.WithVolumes(*volume...),
 		compiler.WithWorkspace(*base, *path),
 	}
 
-	out, _ := compiler.New(opts...).Compile(conf)
+	if cmd == lockCmd.FullCommand() {
+		out, err := compiler.New(opts...).Compile(conf)
+		if err != nil {
+			log.Fatal(err)
+		}
+		lock, err := compiler.GenerateLock(out, compiler.DockerResolver)
+		if err != nil {
+			log.Fatal(err)
+		}
+		f, err := os.Create(*lockTarget)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer f.Close()
+		enc := json.NewEncoder(f)
+		enc.SetIndent("", "\t")
+		enc.Encode(lock)
+		return
+	}
+
+	if *lockfile != "" {
+		lock, err := compiler.ParseLockFile(*lockfile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		opts = append(opts, compiler.WithImageLock(lock, *lockstrict))
+	}
+
+	out, err := compiler.New(opts...).Compile(conf)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *lockfile != "" {
+		for _, stage := range out.Stages {
+			for _, step := range stage.Steps {
+				if !strings.Contains(step.Image, "@") {
+					log.Printf("Image %s not found in the lock file", step.Image)
+				}
+			}
+		}
+	}
 	enc := json.NewEncoder(os.Stdout)
 	enc.SetIndent("", "\t")
 	enc.Encode(out)
@@ -28,9 +28,10 @@ type (
 		DNS           StringSlice            `yaml:"dns,omitempty"`
 		DNSSearch     StringSlice            `yaml:"dns_search,omitempty"`
 		Entrypoint    Command                `yaml:"entrypoint,omitempty"`
-		Environment   SliceMap               `yaml:"environment,omitempty"`
+		Environment   Environment            `yaml:"environment,omitempty"`
 		ExtraHosts    []string               `yaml:"extra_hosts,omitempty"`
 		Group         string                 `yaml:"group,omitempty"`
+		Healthcheck   Healthcheck            `yaml:"healthcheck,omitempty"`
 		Image         string                 `yaml:"image,omitempty"`
 		Isolation     string                 `yaml:"isolation,omitempty"`
 		Labels        SliceMap               `yaml:"labels,omitempty"`
@@ -43,10 +44,13 @@ type (
 		Networks      Networks               `yaml:"networks,omitempty"`
 		Privileged    bool                   `yaml:"privileged,omitempty"`
 		Pull          bool                   `yaml:"pull,omitempty"`
+		Retry         Retry                  `yaml:"retry,omitempty"`
 		Shell         string                 `yaml:"shell,omitempty"`
 		ShmSize       MemStringInt           `yaml:"shm_size,omitempty"`
 		Sysctls       SliceMap               `yaml:"sysctls,omitempty"`
+		Timeout       Duration               `yaml:"timeout,omitempty"`
 		Volumes       []*Volume              `yaml:"volumes,omitempty"`
+		WaitFor       StringSlice            `yaml:"wait_for,omitempty"`
 		Secrets       Secrets                `yaml:"secrets,omitempty"`
 		Reports       Reports                `yaml:"reports,omitempty"`
 		Constraints   Constraints            `yaml:"when,omitempty"`
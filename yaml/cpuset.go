@@ -0,0 +1,58 @@
+package yaml
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ParseCPUSet parses the cpuset string (e.g. 0-3,5) and returns
+// the list of cpus.
+func ParseCPUSet(s string) ([]int, error) {
+	var cpus []int
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		bounds := strings.SplitN(part, "-", 2)
+		lo, err := strconv.Atoi(bounds[0])
+		if err != nil {
+			return nil, fmt.Errorf("Invalid cpuset %s", s)
+		}
+		hi := lo
+		if len(bounds) == 2 {
+			hi, err = strconv.Atoi(bounds[1])
+			if err != nil || hi < lo {
+				return nil, fmt.Errorf("Invalid cpuset %s", s)
+			}
+		}
+		for cpu := lo; cpu <= hi; cpu++ {
+			cpus = append(cpus, cpu)
+		}
+	}
+	return cpus, nil
+}
+
+// CPUSetWithin returns true if every cpu in the cpuset is also
+// in the cpuset limit.
+func CPUSetWithin(set, limit string) bool {
+	cpus, err := ParseCPUSet(set)
+	if err != nil {
+		return false
+	}
+	allowed, err := ParseCPUSet(limit)
+	if err != nil {
+		return false
+	}
+	lookup := map[int]struct{}{}
+	for _, cpu := range allowed {
+		lookup[cpu] = struct{}{}
+	}
+	for _, cpu := range cpus {
+		if _, ok := lookup[cpu]; !ok {
+			return false
+		}
+	}
+	return true
+}
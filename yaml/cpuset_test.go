@@ -0,0 +1,42 @@
+package yaml
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseCPUSet(t *testing.T) {
+	var tests = []struct {
+		from string
+		want []int
+		err  bool
+	}{
+		{from: "", want: nil},
+		{from: "1", want: []int{1}},
+		{from: "0,1", want: []int{0, 1}},
+		{from: "0-3,5", want: []int{0, 1, 2, 3, 5}},
+		{from: "a", err: true},
+		{from: "3-1", err: true},
+	}
+	for _, test := range tests {
+		got, err := ParseCPUSet(test.from)
+		if test.err {
+			if err == nil {
+				t.Errorf("Want error parsing cpuset %q", test.from)
+			}
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("Got cpuset %v want %v", got, test.want)
+		}
+	}
+}
+
+func TestCPUSetWithin(t *testing.T) {
+	if !CPUSetWithin("0,1", "0-3") {
+		t.Errorf("Want cpuset 0,1 within 0-3")
+	}
+	if CPUSetWithin("2-5", "0-3") {
+		t.Errorf("Want cpuset 2-5 not within 0-3")
+	}
+}
@@ -0,0 +1,29 @@
+package yaml
+
+import (
+	"time"
+)
+
+// Duration represents a duration string (e.g. 15m) or an integer
+// number of seconds.
+type Duration time.Duration
+
+// UnmarshalYAML implements the Unmarshaller interface.
+func (d *Duration) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var intType int64
+	if err := unmarshal(&intType); err == nil {
+		*d = Duration(time.Duration(intType) * time.Second)
+		return nil
+	}
+
+	var stringType string
+	if err := unmarshal(&stringType); err != nil {
+		return err
+	}
+
+	duration, err := time.ParseDuration(stringType)
+	if err == nil {
+		*d = Duration(duration)
+	}
+	return err
+}
@@ -0,0 +1,47 @@
+package yaml
+
+import (
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+func TestDuration(t *testing.T) {
+	var tests = []struct {
+		yaml string
+		want time.Duration
+	}{
+		{
+			yaml: "15m",
+			want: 15 * time.Minute,
+		},
+		{
+			yaml: "1h30m",
+			want: 90 * time.Minute,
+		},
+		{
+			yaml: "30",
+			want: 30 * time.Second,
+		},
+	}
+
+	for _, test := range tests {
+		var got Duration
+
+		if err := yaml.Unmarshal([]byte(test.yaml), &got); err != nil {
+			t.Error(err)
+		}
+
+		if test.want != time.Duration(got) {
+			t.Errorf("got duration %v want %v", time.Duration(got), test.want)
+		}
+	}
+}
+
+func TestDurationError(t *testing.T) {
+	var got Duration
+	if err := yaml.Unmarshal([]byte("fifteen"), &got); err == nil {
+		t.Errorf("Want error parsing invalid duration")
+	}
+}
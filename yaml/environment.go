@@ -0,0 +1,86 @@
+package yaml
+
+type (
+	// Environment represents a slice or map of environment variables.
+	// A variable value may reference a named secret using the
+	// from_secret attribute.
+	Environment struct {
+		Map     map[string]string
+		Secrets map[string]string
+	}
+
+	// environValue represents an environment variable value, or a
+	// reference to a named secret.
+	environValue struct {
+		Value      string
+		FromSecret string
+	}
+)
+
+// UnmarshalYAML implements the Unmarshaller interface.
+func (e *Environment) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var slicemap SliceMap
+	if err := unmarshal(&slicemap); err == nil {
+		e.Map = slicemap.Map
+		return nil
+	}
+
+	var mapType map[string]*environValue
+	if err := unmarshal(&mapType); err != nil {
+		return err
+	}
+	e.Map = map[string]string{}
+	for k, v := range mapType {
+		switch {
+		case v == nil:
+			e.Map[k] = ""
+		case v.FromSecret != "":
+			if e.Secrets == nil {
+				e.Secrets = map[string]string{}
+			}
+			e.Secrets[k] = v.FromSecret
+		default:
+			e.Map[k] = v.Value
+		}
+	}
+	return nil
+}
+
+// UnmarshalYAML implements the Unmarshaller interface.
+func (v *environValue) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var stringType string
+	if err := unmarshal(&stringType); err == nil {
+		v.Value = stringType
+		return nil
+	}
+	structType := struct {
+		FromSecret string `yaml:"from_secret"`
+	}{}
+	if err := unmarshal(&structType); err != nil {
+		return err
+	}
+	v.FromSecret = structType.FromSecret
+	return nil
+}
+
+// FromSecret returns the name of the secret referenced by a plugin
+// parameter using the from_secret attribute.
+func FromSecret(v interface{}) (string, bool) {
+	var name interface{}
+	switch m := v.(type) {
+	case map[interface{}]interface{}:
+		if len(m) != 1 {
+			return "", false
+		}
+		name = m["from_secret"]
+	case map[string]interface{}:
+		if len(m) != 1 {
+			return "", false
+		}
+		name = m["from_secret"]
+	default:
+		return "", false
+	}
+	s, ok := name.(string)
+	return s, ok && s != ""
+}
@@ -0,0 +1,83 @@
+package yaml
+
+import (
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+func TestEnvironment(t *testing.T) {
+	var tests = []struct {
+		yaml    string
+		want    map[string]string
+		secrets map[string]string
+	}{
+		{
+			yaml: "[ foo=bar, baz=qux ]",
+			want: map[string]string{"foo": "bar", "baz": "qux"},
+		},
+		{
+			yaml: "{ foo: bar, baz: qux }",
+			want: map[string]string{"foo": "bar", "baz": "qux"},
+		},
+		{
+			yaml:    "{ foo: bar, password: { from_secret: docker_password } }",
+			want:    map[string]string{"foo": "bar"},
+			secrets: map[string]string{"password": "docker_password"},
+		},
+	}
+
+	for _, test := range tests {
+		var got Environment
+
+		if err := yaml.Unmarshal([]byte(test.yaml), &got); err != nil {
+			t.Error(err)
+		}
+		if !reflect.DeepEqual(got.Map, test.want) {
+			t.Errorf("Got environment %v want %v", got.Map, test.want)
+		}
+		if !reflect.DeepEqual(got.Secrets, test.secrets) {
+			t.Errorf("Got environment secrets %v want %v", got.Secrets, test.secrets)
+		}
+	}
+
+	var got Environment
+	if err := yaml.Unmarshal([]byte("1"), &got); err == nil {
+		t.Errorf("Want error unmarshaling invalid environment value.")
+	}
+}
+
+func TestFromSecret(t *testing.T) {
+	var tests = []struct {
+		yaml string
+		name string
+		ok   bool
+	}{
+		{
+			yaml: "{ from_secret: docker_password }",
+			name: "docker_password",
+			ok:   true,
+		},
+		{
+			yaml: "{ from_secret: docker_password, foo: bar }",
+		},
+		{
+			yaml: "docker_password",
+		},
+		{
+			yaml: "[ docker_password ]",
+		},
+	}
+
+	for _, test := range tests {
+		var in interface{}
+		if err := yaml.Unmarshal([]byte(test.yaml), &in); err != nil {
+			t.Error(err)
+		}
+		name, ok := FromSecret(in)
+		if name != test.name || ok != test.ok {
+			t.Errorf("Want secret reference %q %v, got %q %v", test.name, test.ok, name, ok)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package yaml
+
+// Healthcheck represents the service readiness check. The pipeline
+// waits for each of the service endpoints (e.g. tcp://database:3306
+// or http://cache:8080/health) to accept connections before the
+// first pipeline stage is started.
+type Healthcheck struct {
+	WaitFor  StringSlice
+	Timeout  Duration
+	Interval Duration
+}
+
+// UnmarshalYAML implements the Unmarshaller interface.
+func (h *Healthcheck) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var sliceType StringSlice
+	if err := unmarshal(&sliceType); err == nil {
+		h.WaitFor = sliceType
+		return nil
+	}
+
+	structType := struct {
+		WaitFor  StringSlice `yaml:"wait_for"`
+		Timeout  Duration
+		Interval Duration
+	}{}
+	if err := unmarshal(&structType); err != nil {
+		return err
+	}
+	h.WaitFor = structType.WaitFor
+	h.Timeout = structType.Timeout
+	h.Interval = structType.Interval
+	return nil
+}
@@ -0,0 +1,45 @@
+package yaml
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+func TestHealthcheck(t *testing.T) {
+	var tests = []struct {
+		yaml string
+		want Healthcheck
+	}{
+		{
+			yaml: "tcp://database:3306",
+			want: Healthcheck{WaitFor: []string{"tcp://database:3306"}},
+		},
+		{
+			yaml: "[ tcp://database:3306, http://cache:8080/health ]",
+			want: Healthcheck{WaitFor: []string{"tcp://database:3306", "http://cache:8080/health"}},
+		},
+		{
+			yaml: "{ wait_for: tcp://database:3306, timeout: 2m, interval: 5s }",
+			want: Healthcheck{
+				WaitFor:  []string{"tcp://database:3306"},
+				Timeout:  Duration(2 * time.Minute),
+				Interval: Duration(5 * time.Second),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		var got Healthcheck
+
+		if err := yaml.Unmarshal([]byte(test.yaml), &got); err != nil {
+			t.Error(err)
+		}
+
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("got healthcheck %v want %v", got, test.want)
+		}
+	}
+}
@@ -0,0 +1,11 @@
+package yaml
+
+// Retry represents the step retry policy. The step is executed up
+// to the number of attempts, waiting for the delay between attempts.
+// If exit codes are specified, the step is only retried when it
+// exits with one of the exit codes.
+type Retry struct {
+	Attempts    int
+	Delay       Duration
+	OnExitCodes []int `yaml:"on_exit_codes"`
+}
@@ -0,0 +1,26 @@
+package yaml
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+func TestRetry(t *testing.T) {
+	var got Retry
+	in := []byte("{ attempts: 3, delay: 10s, on_exit_codes: [ 1, 137 ] }")
+	if err := yaml.Unmarshal(in, &got); err != nil {
+		t.Error(err)
+		return
+	}
+	want := Retry{
+		Attempts:    3,
+		Delay:       Duration(10 * time.Second),
+		OnExitCodes: []int{1, 137},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got retry %v want %v", got, want)
+	}
+}
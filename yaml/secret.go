@@ -10,9 +10,15 @@ type (
 	Secret struct {
 		Source string
 		Target string
+		Type   string
+		Mode   int64
 	}
 )
 
+// SecretTypeFile specifies the secret is mounted into the container
+// as a file instead of an environment variable.
+const SecretTypeFile = "file"
+
 // UnmarshalYAML implements the Unmarshaller interface.
 func (s *Secrets) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	var strslice []string
@@ -28,3 +34,8 @@ func (s *Secrets) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	}
 	return unmarshal(&s.Secrets)
 }
+
+// IsFile returns true if the secret is mounted as a file.
+func (s *Secret) IsFile() bool {
+	return s.Type == SecretTypeFile
+}
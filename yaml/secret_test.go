@@ -48,6 +48,17 @@ func TestUnmarshalSecrets(t *testing.T) {
 				},
 			},
 		},
+		{
+			from: "[ { source: kubeconfig, target: /root/.kube/config, type: file, mode: 0400 } ]",
+			want: []*Secret{
+				{
+					Source: "kubeconfig",
+					Target: "/root/.kube/config",
+					Type:   "file",
+					Mode:   0400,
+				},
+			},
+		},
 	}
 
 	for _, test := range testdata {
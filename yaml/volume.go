@@ -1,29 +1,67 @@
 package yaml
 
-import "strings"
+import (
+	"fmt"
+	"strings"
+)
+
+// Volume types supported by the long volume syntax.
+const (
+	VolumeTypeVolume = "volume"
+	VolumeTypeBind   = "bind"
+	VolumeTypeTmpfs  = "tmpfs"
+)
 
 // Volume represent a container volume.
 type Volume struct {
 	Source      string
 	Destination string
 	ReadOnly    bool
+	Type        string
+	TmpfsSize   int64
 }
 
 // UnmarshalYAML implements the Unmarshaller interface.
 func (v *Volume) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	var stringType string
-	if err := unmarshal(&stringType); err != nil {
+	if err := unmarshal(&stringType); err == nil {
+		parts := strings.SplitN(stringType, ":", 3)
+		switch {
+		case len(parts) == 2:
+			v.Source = parts[0]
+			v.Destination = parts[1]
+		case len(parts) == 3:
+			v.Source = parts[0]
+			v.Destination = parts[1]
+			v.ReadOnly = parts[2] == "ro"
+		}
+		return nil
+	}
+
+	structType := struct {
+		Type     string
+		Source   string
+		Target   string
+		ReadOnly bool `yaml:"read_only"`
+		Tmpfs    struct {
+			Size MemStringInt
+		}
+	}{}
+	if err := unmarshal(&structType); err != nil {
 		return err
 	}
-	parts := strings.SplitN(stringType, ":", 3)
-	switch {
-	case len(parts) == 2:
-		v.Source = parts[0]
-		v.Destination = parts[1]
-	case len(parts) == 3:
-		v.Source = parts[0]
-		v.Destination = parts[1]
-		v.ReadOnly = parts[2] == "ro"
+	switch structType.Type {
+	case "", VolumeTypeVolume, VolumeTypeBind, VolumeTypeTmpfs:
+	default:
+		return fmt.Errorf("Invalid volume type %s", structType.Type)
+	}
+	if structType.Target == "" {
+		return fmt.Errorf("Invalid or missing volume target")
 	}
+	v.Type = structType.Type
+	v.Source = structType.Source
+	v.Destination = structType.Target
+	v.ReadOnly = structType.ReadOnly
+	v.TmpfsSize = int64(structType.Tmpfs.Size)
 	return nil
 }
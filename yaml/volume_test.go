@@ -40,4 +40,41 @@ func TestVolume(t *testing.T) {
 	if err := yaml.Unmarshal([]byte("{}"), &got); err == nil {
 		t.Errorf("Want error unmarshaling invalid volume string.")
 	}
+	if err := yaml.Unmarshal([]byte("{ type: nfs, target: /cache }"), &got); err == nil {
+		t.Errorf("Want error unmarshaling invalid volume type.")
+	}
+}
+
+func TestVolumeLongSyntax(t *testing.T) {
+	var tests = []struct {
+		yaml string
+		want Volume
+	}{
+		{
+			yaml: "{ type: volume, source: cache, target: /cache, read_only: true }",
+			want: Volume{Type: "volume", Source: "cache", Destination: "/cache", ReadOnly: true},
+		},
+		{
+			yaml: "{ type: bind, source: /opt/data, target: /var/lib/mysql }",
+			want: Volume{Type: "bind", Source: "/opt/data", Destination: "/var/lib/mysql"},
+		},
+		{
+			yaml: "{ type: tmpfs, target: /tmp, tmpfs: { size: 64mb } }",
+			want: Volume{Type: "tmpfs", Destination: "/tmp", TmpfsSize: 67108864},
+		},
+		{
+			yaml: "{ source: cache, target: /cache }",
+			want: Volume{Source: "cache", Destination: "/cache"},
+		},
+	}
+
+	for _, test := range tests {
+		got := Volume{}
+		if err := yaml.Unmarshal([]byte(test.yaml), &got); err != nil {
+			t.Errorf("got error unmarshaling volume %q", test.yaml)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("got volume %v want %v", got, test.want)
+		}
+	}
 }